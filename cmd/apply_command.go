@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/d4rthvadr/node-cleaner/internal/analyzer"
+	"github.com/d4rthvadr/node-cleaner/internal/config"
+	"github.com/d4rthvadr/node-cleaner/internal/plan"
+	"github.com/d4rthvadr/node-cleaner/internal/policy"
+	"github.com/d4rthvadr/node-cleaner/internal/project"
+	"github.com/d4rthvadr/node-cleaner/internal/workspace"
+	"github.com/d4rthvadr/node-cleaner/pkg/models"
+	"github.com/dustin/go-humanize"
+	"github.com/spf13/cobra"
+)
+
+var applyCmd = &cobra.Command{
+	Use:   "apply <plan.json>",
+	Short: "Delete the folders listed in a plan written by clean --plan",
+	Long: `Delete the folders listed in a reviewed plan. Every folder is checked
+against the plan first; folders whose size, mtime or contents drifted since
+planning are refused and reported as failures.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runApply,
+}
+
+func init() {
+	applyCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Check the plan without deleting anything")
+	applyCmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "Apply without prompting")
+	applyCmd.Flags().BoolVar(&allowTracked, "allow-tracked", false, "Allow deleting folders that contain git-tracked files")
+	applyCmd.Flags().BoolVar(&ignoreInUse, "ignore-in-use", false, "Delete folders even when running processes are using them")
+
+	rootCmd.AddCommand(applyCmd)
+}
+
+func runApply(cmd *cobra.Command, args []string) error {
+	cfg := config.Load()
+
+	p, err := plan.Read(args[0])
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Plan from %s by %s on %s: %d folders, %s\n", p.CreatedAt.Local().Format("2006-01-02 15:04"),
+		p.CreatedBy, p.Host, len(p.Entries), humanize.Bytes(uint64(p.TotalSize())))
+
+	a := analyzer.NewAnalyzer()
+	a.SetLogger(appLogger)
+
+	projects := project.NewDetector()
+	workspaces := workspace.NewFinder()
+	rules, err := policy.Compile(cfg.Policies)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+
+	var selected, planned []models.DependencyFolder
+	var refused []models.FailedOp
+
+	for _, entry := range p.Entries {
+		entryFolder := models.DependencyFolder{Path: entry.Path, Type: entry.Ecosystem}
+		entryFolder.Workspace = workspaces.Find(entryFolder)
+		planned = append(planned, entryFolder)
+
+		folder, err := plan.Verify(a, entry)
+		if err != nil {
+			if errors.Is(err, plan.ErrDrifted) {
+				appLogger.Warn("refusing drifted plan entry", "path", entry.Path, "reason", err)
+			}
+			refused = append(refused, models.FailedOp{Path: entry.Path, Reason: err.Error()})
+			continue
+		}
+		if reason := rules.Protects(*folder, now); reason != "" {
+			refused = append(refused, models.FailedOp{Path: entry.Path, Reason: reason})
+			continue
+		}
+		folder.Project = projects.Detect(*folder)
+		folder.Workspace = entryFolder.Workspace
+		selected = append(selected, *folder)
+	}
+
+	// a workspace is deleted whole or not at all, so one refused member
+	// refuses the rest of it
+	kept, skipped := workspace.Complete(selected, planned)
+	printLines(skipped)
+	if len(kept) < len(selected) {
+		keep := make(map[string]bool, len(kept))
+		for _, f := range kept {
+			keep[f.Path] = true
+		}
+		for _, f := range selected {
+			if !keep[f.Path] {
+				refused = append(refused, models.FailedOp{Path: f.Path,
+					Reason: "another folder of workspace " + f.Workspace.Name + " was refused"})
+			}
+		}
+		selected = kept
+	}
+
+	if len(refused) > 0 {
+		fmt.Printf("%d planned folders changed, are protected or belong to a workspace that did, and will be skipped.\n", len(refused))
+	}
+
+	if len(selected) > 0 && !dryRun && !assumeYes {
+		fmt.Printf("\nAre you sure you want to delete %d planned folders? (y/n): ", len(selected))
+		var confirm string
+		fmt.Scanln(&confirm)
+		if confirm != "y" && confirm != "Y" {
+			fmt.Println("Aborting deletion.")
+			return nil
+		}
+	}
+
+	cl := newCleaner(cfg, p.Root)
+	cleanResult, err := cl.Clean(cmd.Context(), selected)
+	if err != nil {
+		return fmt.Errorf("cleaning folders: %w", err)
+	}
+	cleanResult.Failed = append(cleanResult.Failed, refused...)
+
+	finishClean(cfg, selected, cleanResult)
+	return nil
+}
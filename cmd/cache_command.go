@@ -8,6 +8,8 @@ import (
 	"github.com/spf13/cobra"
 )
 
+var migrateTo string
+
 var cacheCmd = &cobra.Command{
 	Use:   "cache",
 	Short: "Manage cache",
@@ -19,19 +21,29 @@ var cacheClearCmd = &cobra.Command{
 	RunE:  runCacheClear,
 }
 
+var cacheMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Copy cache entries to another backend and switch to it",
+	RunE:  runCacheMigrate,
+}
+
 func init() {
+	cacheMigrateCmd.Flags().StringVar(&migrateTo, "to", cache.BackendLog, "Target cache backend (json|log)")
+
 	cacheCmd.AddCommand(cacheClearCmd)
+	cacheCmd.AddCommand(cacheMigrateCmd)
 	rootCmd.AddCommand(cacheCmd)
 }
 
 func runCacheClear(cmd *cobra.Command, args []string) error {
 	cfg := config.Load()
 
-	c, err := cache.NewCache(cfg.CachePath)
+	c, err := cache.Open(cfg.CacheBackend, cfg.CachePath, appLogger)
 	if err != nil {
 		fmt.Printf("failed to load cache: %v\n", err)
 		return err
 	}
+	defer c.Close()
 
 	if err := c.Clear(); err != nil {
 		fmt.Printf("failed to clear cache: %v\n", err)
@@ -42,3 +54,40 @@ func runCacheClear(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+func runCacheMigrate(cmd *cobra.Command, args []string) error {
+	cfg := config.Load()
+
+	from := cfg.CacheBackend
+	if from == "" {
+		from = cache.BackendJSON
+	}
+	if from == migrateTo {
+		fmt.Printf("Cache already uses the %s backend.\n", from)
+		return nil
+	}
+
+	src, err := cache.Open(from, cfg.CachePath, appLogger)
+	if err != nil {
+		return fmt.Errorf("opening %s cache: %w", from, err)
+	}
+	defer src.Close()
+
+	dst, err := cache.Open(migrateTo, cfg.CachePath, appLogger)
+	if err != nil {
+		return fmt.Errorf("opening %s cache: %w", migrateTo, err)
+	}
+	defer dst.Close()
+
+	n, err := cache.Migrate(src, dst)
+	if err != nil {
+		return err
+	}
+
+	if err := config.Set("cache_backend", migrateTo); err != nil {
+		return fmt.Errorf("switching cache backend: %w", err)
+	}
+
+	fmt.Printf("Migrated %d entries from %s to %s backend.\n", n, from, migrateTo)
+	return nil
+}
@@ -4,11 +4,23 @@ import (
 	"fmt"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/d4rthvadr/node-cleaner/internal/analyzer"
+	"github.com/d4rthvadr/node-cleaner/internal/archive"
+	"github.com/d4rthvadr/node-cleaner/internal/audit"
 	"github.com/d4rthvadr/node-cleaner/internal/cache"
 	"github.com/d4rthvadr/node-cleaner/internal/cleaner"
 	"github.com/d4rthvadr/node-cleaner/internal/config"
+	"github.com/d4rthvadr/node-cleaner/internal/filter"
+	"github.com/d4rthvadr/node-cleaner/internal/goal"
+	"github.com/d4rthvadr/node-cleaner/internal/plan"
+	"github.com/d4rthvadr/node-cleaner/internal/policy"
+	"github.com/d4rthvadr/node-cleaner/internal/quarantine"
+	"github.com/d4rthvadr/node-cleaner/internal/restore"
 	"github.com/d4rthvadr/node-cleaner/internal/scanner"
 	"github.com/d4rthvadr/node-cleaner/internal/ui"
+	"github.com/d4rthvadr/node-cleaner/internal/workspace"
+	"github.com/d4rthvadr/node-cleaner/pkg/models"
+	"github.com/dustin/go-humanize"
 	"github.com/spf13/cobra"
 )
 
@@ -16,12 +28,42 @@ var (
 	noCacheClean bool
 	dryRun       bool
 	cleanPath    string
+	useTrash     bool
+	quarantined  bool
+	allowTracked bool
+	ignoreInUse  bool
+	background   bool
+	archiveDir   string
+	assumeYes    bool
+	interactive  bool
+	filterOpts   filter.Options
+	planPath     string
+	usePolicy    bool
+	targetFree   string
+	reclaimGoal  string
 )
 
 var cleanCmd = &cobra.Command{
 	Use:   "clean",
 	Short: "Interactive clean (scan + select + delete)",
-	RunE:  runClean,
+	Long: `Scan a path, select dependency folders and delete them.
+
+Filters narrow down the folders offered for selection. Combined with --yes
+(or --interactive=false) every matching folder is deleted without prompts,
+which suits scripts and cron:
+
+  depo-cleaner clean ~/projects --older-than 90d --min-size 200MB --type node,rust --yes
+
+With --target-free or --reclaim the folders are chosen for you: the longest
+idle ones are picked until deleting them frees enough space on the disk
+holding the scanned path, and the projection is shown before anything is
+deleted:
+
+  depo-cleaner clean ~/projects --target-free 50GB
+  depo-cleaner clean ~/projects --reclaim 20GB --yes`,
+	// finish deletions an earlier run left behind
+	PreRun: reapLeftovers,
+	RunE:   runClean,
 }
 
 func init() {
@@ -29,6 +71,25 @@ func init() {
 	cleanCmd.Flags().BoolVar(&noCacheClean, "no-cache", false, "Disable cache")
 	cleanCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Perform a preview run with no files deleted")
 	cleanCmd.Flags().StringVar(&cleanPath, "path", "", "path to scan (default: $HOME)")
+	cleanCmd.Flags().BoolVar(&quarantined, "quarantine", false, "Move folders to the quarantine area so they can be restored later")
+	cleanCmd.Flags().BoolVar(&allowTracked, "allow-tracked", false, "Allow deleting folders that contain git-tracked files")
+	cleanCmd.Flags().BoolVar(&ignoreInUse, "ignore-in-use", false, "Delete folders even when running processes are using them")
+	cleanCmd.Flags().StringVar(&archiveDir, "archive", "", "Write a verified tar.gz of each folder to this directory before deleting it")
+	cleanCmd.Flags().BoolVar(&background, "background", false, "Return once folders are renamed and finish deleting them in the background")
+	cleanCmd.Flags().BoolVar(&useTrash, "trash", false, "Move folders to the trash instead of deleting them (default from use_trash config)")
+
+	cleanCmd.Flags().StringVar(&filterOpts.OlderThan, "older-than", "", "Only folders not used for this long (e.g. 90d, 2w)")
+	cleanCmd.Flags().StringVar(&filterOpts.MinSize, "min-size", "", "Only folders at least this large (e.g. 200MB)")
+	cleanCmd.Flags().StringSliceVar(&filterOpts.Types, "type", nil, "Only these ecosystems (node, python, rust, go, php)")
+	cleanCmd.Flags().StringArrayVar(&filterOpts.PathGlobs, "path-glob", nil, "Only folders whose path matches this glob (repeatable, ** crosses directories)")
+	cleanCmd.Flags().StringArrayVar(&filterOpts.Excludes, "exclude", nil, "Skip folders whose path matches this glob (repeatable)")
+	cleanCmd.Flags().IntVar(&filterOpts.MinScore, "min-score", 0, "Only folders whose staleness score is at least this (0-100)")
+	cleanCmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "Delete every matching folder without prompting")
+	cleanCmd.Flags().BoolVar(&interactive, "interactive", true, "Select folders in the interactive selector")
+	cleanCmd.Flags().BoolVar(&usePolicy, "policy", false, "Apply the configured policies without prompting: auto-clean and trash matching folders")
+	cleanCmd.Flags().StringVar(&targetFree, "target-free", "", "Choose folders until the disk has this much free space (e.g. 50GB or 20%)")
+	cleanCmd.Flags().StringVar(&reclaimGoal, "reclaim", "", "Choose folders until this much space is reclaimed (e.g. 20GB)")
+	cleanCmd.Flags().StringVar(&planPath, "plan", "", "Write the selected folders to this plan file for review instead of deleting them (run it with apply)")
 
 	rootCmd.AddCommand(cleanCmd)
 }
@@ -39,7 +100,55 @@ func runClean(cmd *cobra.Command, args []string) error {
 	path := cleanPath
 
 	cfg := config.Load()
-	fmt.Printf("Scanning path: %s %v\n", path, cfg.ScanPath)
+
+	folderFilter, err := filter.Parse(filterOpts)
+	if err != nil {
+		return err
+	}
+
+	// --yes implies selecting every match without the selector
+	unattended := assumeYes || !interactive
+	goalMode := targetFree != "" || reclaimGoal != ""
+	if goalMode && usePolicy {
+		return fmt.Errorf("--target-free and --reclaim cannot be combined with --policy")
+	}
+	if unattended && folderFilter.IsEmpty() && !usePolicy && !goalMode {
+		return fmt.Errorf("non-interactive clean needs at least one filter (--older-than, --min-size, --type, --path-glob, --exclude or --min-score)")
+	}
+
+	// protect rules apply to every clean, not only --policy
+	rules, err := policy.Compile(cfg.Policies)
+	if err != nil {
+		return err
+	}
+	if usePolicy && rules.IsEmpty() {
+		return fmt.Errorf("--policy needs rules in the policies section of the config")
+	}
+
+	if cmd.Flags().Changed("trash") {
+		cfg.UseTrash = useTrash
+	}
+	if quarantined && cfg.UseTrash {
+		if cmd.Flags().Changed("trash") {
+			return fmt.Errorf("--quarantine and --trash cannot be combined")
+		}
+		cfg.UseTrash = false // explicit flag beats the config default
+	}
+
+	if archiveDir != "" && (quarantined || cfg.UseTrash) {
+		if quarantined || cmd.Flags().Changed("trash") {
+			return fmt.Errorf("--archive cannot be combined with --quarantine or --trash")
+		}
+		cfg.UseTrash = false
+	}
+
+	// moving folders elsewhere does not free the space the goal is about
+	if goalMode && (quarantined || cfg.UseTrash) {
+		if quarantined || cmd.Flags().Changed("trash") {
+			return fmt.Errorf("--target-free and --reclaim cannot be combined with --quarantine or --trash")
+		}
+		cfg.UseTrash = false
+	}
 
 	if len(args) > 0 {
 		path = args[0]
@@ -50,47 +159,129 @@ func runClean(cmd *cobra.Command, args []string) error {
 	}
 	cfg.ScanPath = path
 
-	var c *cache.Cache
-	var err error
+	var spaceGoal *goal.Goal
+	if goalMode {
+		disk, err := goal.Stat(path)
+		if err != nil {
+			return err
+		}
+		if spaceGoal, err = goal.Parse(targetFree, reclaimGoal, disk); err != nil {
+			return err
+		}
+		if spaceGoal.Need == 0 {
+			fmt.Printf("%s already has %s free, nothing to clean.\n", path, humanize.Bytes(uint64(disk.Free)))
+			return nil
+		}
+	}
+
+	var c cache.Backend
 
 	if !noCacheClean {
-		c, err = cache.NewCache(cfg.CachePath)
+		c, err = cache.Open(cfg.CacheBackend, cfg.CachePath, appLogger)
 		if err != nil {
 			return err
 		}
-		defer c.Save()
+		defer c.Close()
 	}
 
 	scanner := scanner.NewScanner(cfg, c)
+	scanner.SetLogger(appLogger)
+	scanner.SetPolicy(rules)
 
 	result, err := scanner.Scan(ctx, path)
 	if err != nil {
 		return fmt.Errorf("scanning: %w", err)
 	}
 
-	if len(result.Folders) == 0 {
+	candidates := folderFilter.Apply(result.Folders)
+	if len(candidates) == 0 {
 		fmt.Println("No dependency folders found to clean.")
 		return nil
 	}
 
-	// Interactive selection and deletion
-	model := ui.NewSelectionModel(result.Folders)
-	p := tea.NewProgram(model)
+	// without the selector, a workspace is only cleaned when all of its
+	// folders qualify, since deleting some of them breaks the install
+	if unattended || usePolicy || spaceGoal != nil {
+		var skipped []string
+		candidates, skipped = workspace.Complete(candidates, result.Folders)
+		printLines(skipped)
+		if len(candidates) == 0 {
+			fmt.Println("No dependency folders found to clean.")
+			return nil
+		}
+	}
 
-	finalModel, err := p.Run()
-	if err != nil {
-		return fmt.Errorf("running UI: %w", err)
+	if usePolicy {
+		return cleanByPolicy(ctx, cfg, path, rules, candidates)
 	}
 
-	selected := finalModel.(*ui.SelectionModel).GetSelectedFolders()
+	var selected []models.DependencyFolder
+	switch {
+	case spaceGoal != nil:
+		sizeOf := func(f models.DependencyFolder) (int64, error) {
+			return goal.Reclaimable(f.Path, spaceGoal.Disk.Dev)
+		}
+		sel := spaceGoal.Select(candidates, sizeOf)
+
+		// project what is actually deleted: whole workspaces, not only the
+		// members the goal picked
+		var added []string
+		selected, added = workspace.Expand(sel.Folders(), candidates)
+		sel.Update(selected, sizeOf)
+		printLines(added)
+		ui.DisplayGoalSelection(sel)
+		if len(sel.Chosen) == 0 {
+			fmt.Println("No folders would free space on that disk.")
+			return nil
+		}
+		if !sel.Met() && unattended {
+			return fmt.Errorf("deleting every candidate falls short of the goal; loosen the filters or lower the goal")
+		}
+	case unattended:
+		for _, folder := range candidates {
+			if folder.Protected != "" {
+				fmt.Printf("Skipping %s: %s\n", folder.Path, folder.Protected)
+				continue
+			}
+			selected = append(selected, folder)
+		}
+		fmt.Printf("%d folders match the filters.\n", len(selected))
+	default:
+		// Interactive selection and deletion
+		model := ui.NewSelectionModel(candidates)
+		p := tea.NewProgram(model)
+
+		finalModel, err := p.Run()
+		if err != nil {
+			return fmt.Errorf("running UI: %w", err)
+		}
+
+		var changed []string
+		selected, changed = workspace.Expand(finalModel.(*ui.SelectionModel).GetSelectedFolders(), result.Folders)
+		printLines(changed)
+	}
 
 	if len(selected) == 0 {
 		fmt.Println("No folders selected for deletion.")
 		return nil
 	}
 
-	if !dryRun {
-		fmt.Printf("\nAre you sure you want to delete %d selected folders? (y/n): ", len(selected))
+	if planPath != "" {
+		return writePlan(path, selected)
+	}
+
+	if !dryRun && !unattended {
+		ui.DisplayProjects("Selected projects:", selected)
+
+		action := "delete"
+		if archiveDir != "" {
+			action = "archive and delete"
+		} else if quarantined {
+			action = "quarantine"
+		} else if cfg.UseTrash {
+			action = "move to trash"
+		}
+		fmt.Printf("\nAre you sure you want to %s %d selected folders? (y/n): ", action, len(selected))
 		var confirm string
 		fmt.Scanln(&confirm)
 		if confirm != "y" && confirm != "Y" {
@@ -99,9 +290,7 @@ func runClean(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	//logger := initLogger()
-
-	cl := cleaner.NewCleaner(dryRun, nil)
+	cl := newCleaner(cfg, path)
 
 	cleanResult, err := cl.Clean(ctx, selected)
 
@@ -109,6 +298,82 @@ func runClean(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("cleaning folders: %w", err)
 	}
 
+	finishClean(cfg, selected, cleanResult)
+	return nil
+}
+
+// newCleaner returns a cleaner set up from the config and the shared
+// clean flags, limited to folders under root
+func newCleaner(cfg *models.Config, root string) *cleaner.Cleaner {
+	cl := cleaner.NewCleaner(dryRun, appLogger)
+	cl.SetTrash(cfg.UseTrash)
+	cl.SetWorkers(cfg.Workers)
+	cl.SetRoots(root)
+	cl.SetProtected(cfg.ProtectedPaths)
+	cl.SetAllowTracked(allowTracked)
+	cl.SetIgnoreInUse(ignoreInUse)
+	cl.SetTombstones(cleaner.NewTombstoneRegistry(cfg.TombstonePath))
+	cl.SetProgress(ui.NewCleanProgress())
+	cl.SetBackground(background)
+	if quarantined {
+		cl.SetQuarantine(quarantine.NewStore(cfg.QuarantinePath))
+	}
+	if archiveDir != "" {
+		cl.SetArchive(archive.NewStore(archiveDir))
+	}
+	return cl
+}
+
+func printLines(lines []string) {
+	for _, line := range lines {
+		fmt.Println(line)
+	}
+}
+
+// finishClean starts background deletion if needed, records the run in
+// the audit log and the restore index, and prints the results
+func finishClean(cfg *models.Config, selected []models.DependencyFolder, cleanResult *models.CleanResult) {
+	if len(cleanResult.Tombstones) > 0 {
+		if err := spawnReaper(); err != nil {
+			cleanResult.Warnings = append(cleanResult.Warnings,
+				fmt.Sprintf("could not start background deletion (%v); run depo-cleaner reap to finish", err))
+		}
+	}
+
+	if err := audit.Append(cfg.AuditPath, audit.FromCleanResult(selected, cleanResult)); err != nil {
+		fmt.Printf("failed to write audit log: %v\n", err)
+	}
+	if err := restore.NewIndex(cfg.RestorePath).Record(restore.FromClean(selected, cleanResult)); err != nil {
+		fmt.Printf("failed to write restore hints: %v\n", err)
+	}
+
 	ui.DisplayCleanResults(cleanResult)
-	return err
+
+	deleted := make(map[string]bool, len(cleanResult.DeletedFolders))
+	for _, path := range cleanResult.DeletedFolders {
+		deleted[path] = true
+	}
+	var cleaned []models.DependencyFolder
+	for _, f := range selected {
+		if deleted[f.Path] {
+			cleaned = append(cleaned, f)
+		}
+	}
+	ui.DisplayProjects("Cleaned projects:", cleaned)
+}
+
+func writePlan(root string, selected []models.DependencyFolder) error {
+	a := analyzer.NewAnalyzer()
+	a.SetLogger(appLogger)
+	p, err := plan.New(a, root, selected)
+	if err != nil {
+		return err
+	}
+	if err := plan.Write(planPath, p); err != nil {
+		return fmt.Errorf("writing plan: %w", err)
+	}
+
+	fmt.Printf("Planned %d folders (%s) in %s\n", len(p.Entries), humanize.Bytes(uint64(p.TotalSize())), planPath)
+	fmt.Printf("Review it, then run: depo-cleaner apply %s\n", planPath)
+	return nil
 }
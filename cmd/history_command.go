@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/d4rthvadr/node-cleaner/internal/audit"
+	"github.com/d4rthvadr/node-cleaner/internal/config"
+	"github.com/d4rthvadr/node-cleaner/internal/ui"
+	"github.com/d4rthvadr/node-cleaner/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	historySince string
+	historyUntil string
+	historyPath  string
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Show past clean runs from the audit log",
+	RunE:  runHistory,
+}
+
+func init() {
+	historyCmd.Flags().StringVar(&historySince, "since", "", "Only show records after this date (2006-01-02) or age (e.g. 30d)")
+	historyCmd.Flags().StringVar(&historyUntil, "until", "", "Only show records before this date (2006-01-02) or age (e.g. 7d)")
+	historyCmd.Flags().StringVar(&historyPath, "path", "", "Only show folders at or under this path")
+
+	rootCmd.AddCommand(historyCmd)
+}
+
+func runHistory(cmd *cobra.Command, args []string) error {
+	cfg := config.Load()
+
+	var filter audit.Filter
+	var err error
+
+	if filter.Since, err = parseTimeBound(historySince, false); err != nil {
+		return fmt.Errorf("--since: %w", err)
+	}
+	if filter.Until, err = parseTimeBound(historyUntil, true); err != nil {
+		return fmt.Errorf("--until: %w", err)
+	}
+	if historyPath != "" {
+		if filter.Path, err = filepath.Abs(historyPath); err != nil {
+			return err
+		}
+	}
+
+	records, err := audit.Read(cfg.AuditPath, filter)
+	if err != nil {
+		return fmt.Errorf("reading audit log: %w", err)
+	}
+
+	if len(records) == 0 {
+		fmt.Println("No clean history found.")
+		return nil
+	}
+
+	ui.DisplayHistory(records)
+	return nil
+}
+
+// parseTimeBound accepts a calendar date or an age relative to now.
+// Dates used as an upper bound include the whole day.
+func parseTimeBound(value string, endOfDay bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		if endOfDay {
+			t = t.Add(24*time.Hour - time.Nanosecond)
+		}
+		return t, nil
+	}
+
+	age, err := utils.ParseAge(value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("want a date like 2006-01-02 or an age like 30d, got %q", value)
+	}
+	return time.Now().Add(-age), nil
+}
@@ -0,0 +1,195 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/d4rthvadr/node-cleaner/internal/cache"
+	"github.com/d4rthvadr/node-cleaner/internal/config"
+	"github.com/d4rthvadr/node-cleaner/internal/policy"
+	"github.com/d4rthvadr/node-cleaner/internal/scanner"
+	"github.com/d4rthvadr/node-cleaner/internal/ui"
+	"github.com/d4rthvadr/node-cleaner/internal/workspace"
+	"github.com/d4rthvadr/node-cleaner/pkg/models"
+	"github.com/spf13/cobra"
+)
+
+var policyCmd = &cobra.Command{
+	Use:   "policy",
+	Short: "Inspect the cleanup policies from the config",
+}
+
+var policyCheckCmd = &cobra.Command{
+	Use:   "check [path]",
+	Short: "Show which policy rule applies to each scanned folder",
+	Long: `Scan a path and show, for every dependency folder found, the first rule
+from the policies section of the config that matches it and its action.
+Nothing is deleted.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runPolicyCheck,
+}
+
+func init() {
+	policyCmd.AddCommand(policyCheckCmd)
+	rootCmd.AddCommand(policyCmd)
+}
+
+func runPolicyCheck(cmd *cobra.Command, args []string) error {
+	cfg := config.Load()
+
+	rules, err := policy.Compile(cfg.Policies)
+	if err != nil {
+		return err
+	}
+	if rules.IsEmpty() {
+		fmt.Println("No policies configured; add rules to the policies section of the config.")
+		return nil
+	}
+
+	path := cfg.ScanPath
+	if len(args) > 0 {
+		path = args[0]
+	}
+	if path == "" {
+		path = os.Getenv("HOME")
+	}
+	cfg.ScanPath = path
+
+	c, err := cache.Open(cfg.CacheBackend, cfg.CachePath, appLogger)
+	if err != nil {
+		return fmt.Errorf("opening cache: %w", err)
+	}
+	defer c.Close()
+
+	s := scanner.NewScanner(cfg, c)
+	s.SetLogger(appLogger)
+
+	result, err := s.Scan(cmd.Context(), path)
+	if err != nil {
+		return fmt.Errorf("scanning: %w", err)
+	}
+
+	now := time.Now()
+	decisions := make([]policy.Decision, len(result.Folders))
+	for i, folder := range result.Folders {
+		decisions[i] = rules.Evaluate(folder, now)
+	}
+
+	ui.DisplayPolicyCheck(result.Folders, decisions)
+	return nil
+}
+
+// cleanByPolicy deletes or trashes the folders the policies select for it,
+// without prompting, and lists the folders they only suggest
+func cleanByPolicy(ctx context.Context, cfg *models.Config, root string, rules *policy.Policy, folders []models.DependencyFolder) error {
+	now := time.Now()
+
+	var autoClean, trashed, suggested []models.DependencyFolder
+	for _, folder := range folders {
+		decision := rules.Evaluate(folder, now)
+		appLogger.Debug("policy decision", "path", folder.Path, "rule", decision.Rule, "action", string(decision.Action))
+
+		switch decision.Action {
+		case policy.ActionAutoClean:
+			autoClean = append(autoClean, folder)
+		case policy.ActionTrash:
+			trashed = append(trashed, folder)
+		case policy.ActionSuggest:
+			suggested = append(suggested, folder)
+		}
+	}
+
+	// a workspace split between actions is left alone rather than broken
+	cleanable, skipped := workspace.Complete(append(autoClean, trashed...), folders)
+	printLines(skipped)
+	keep := make(map[string]bool, len(cleanable))
+	for _, f := range cleanable {
+		keep[f.Path] = true
+	}
+	autoClean = slices.DeleteFunc(autoClean, func(f models.DependencyFolder) bool { return !keep[f.Path] })
+	trashed = slices.DeleteFunc(trashed, func(f models.DependencyFolder) bool { return !keep[f.Path] })
+
+	if len(suggested) > 0 {
+		fmt.Printf("%d folders are suggested for cleanup by policy (not cleaned automatically):\n", len(suggested))
+		for _, folder := range suggested {
+			fmt.Printf(" - %s\n", folder.Path)
+		}
+	}
+
+	if len(autoClean)+len(trashed) == 0 {
+		fmt.Println("No folders are set to auto-clean or trash by policy.")
+		return nil
+	}
+
+	result := &models.CleanResult{DryRun: dryRun}
+	start := time.Now()
+
+	for _, batch := range []struct {
+		folders []models.DependencyFolder
+		trash   bool
+	}{
+		{autoClean, false},
+		{trashed, true},
+	} {
+		if len(batch.folders) == 0 {
+			continue
+		}
+
+		cl := newCleaner(cfg, root)
+		if batch.trash {
+			// trash rules win over --quarantine and --archive
+			cl.SetQuarantine(nil)
+			cl.SetArchive(nil)
+			cl.SetTrash(true)
+		} else {
+			cl.SetTrash(false)
+		}
+
+		batchResult, err := cl.Clean(ctx, batch.folders)
+		if err != nil {
+			return fmt.Errorf("cleaning folders: %w", err)
+		}
+		mergeCleanResults(result, batchResult)
+	}
+	result.Duration = time.Since(start)
+
+	finishClean(cfg, append(autoClean, trashed...), result)
+	return nil
+}
+
+// mergeCleanResults folds src into dst
+func mergeCleanResults(dst, src *models.CleanResult) {
+	dst.DeletedFolders = append(dst.DeletedFolders, src.DeletedFolders...)
+	dst.Failed = append(dst.Failed, src.Failed...)
+	dst.SpaceReclaimed += src.SpaceReclaimed
+	dst.SpaceMoved += src.SpaceMoved
+	dst.Warnings = append(dst.Warnings, src.Warnings...)
+	dst.Tombstones = append(dst.Tombstones, src.Tombstones...)
+	dst.ArchivedSize += src.ArchivedSize
+
+	mergeMap(&dst.TrashedTo, src.TrashedTo)
+	mergeMap(&dst.QuarantineIDs, src.QuarantineIDs)
+	mergeMap(&dst.ArchivedTo, src.ArchivedTo)
+
+	if dst.FolderDurations == nil {
+		dst.FolderDurations = make(map[string]time.Duration)
+	}
+	for path, d := range src.FolderDurations {
+		dst.FolderDurations[path] = d
+	}
+}
+
+func mergeMap(dst *map[string]string, src map[string]string) {
+	if len(src) == 0 {
+		return
+	}
+	if *dst == nil {
+		*dst = make(map[string]string)
+	}
+	for k, v := range src {
+		(*dst)[k] = v
+	}
+}
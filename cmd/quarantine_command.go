@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/d4rthvadr/node-cleaner/internal/archive"
+	"github.com/d4rthvadr/node-cleaner/internal/config"
+	"github.com/d4rthvadr/node-cleaner/internal/quarantine"
+	"github.com/d4rthvadr/node-cleaner/internal/ui"
+	"github.com/d4rthvadr/node-cleaner/pkg/utils"
+	"github.com/dustin/go-humanize"
+	"github.com/spf13/cobra"
+)
+
+var (
+	purgeOlderThan string
+	fromArchive    string
+)
+
+var quarantineCmd = &cobra.Command{
+	Use:   "quarantine",
+	Short: "Manage folders held in quarantine by clean --quarantine",
+}
+
+var quarantineListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List quarantined folders",
+	RunE:  runQuarantineList,
+}
+
+var quarantinePurgeCmd = &cobra.Command{
+	Use:   "purge",
+	Short: "Permanently delete quarantined folders",
+	RunE:  runQuarantinePurge,
+}
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore <id|path>",
+	Short: "Move a quarantined folder back to its original location",
+	Long: `Move a quarantined folder back to its original location.
+With --from-archive, extract a folder archived by clean --archive instead,
+found by archive name or original path.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runRestore,
+}
+
+func init() {
+	quarantinePurgeCmd.Flags().StringVar(&purgeOlderThan, "older-than", "7d", "Only purge folders quarantined longer than this (e.g. 7d, 2w, 12h)")
+
+	restoreCmd.Flags().StringVar(&fromArchive, "from-archive", "", "Restore from the archive directory used with clean --archive")
+
+	quarantineCmd.AddCommand(quarantineListCmd)
+	quarantineCmd.AddCommand(quarantinePurgeCmd)
+	rootCmd.AddCommand(quarantineCmd)
+	rootCmd.AddCommand(restoreCmd)
+}
+
+func runQuarantineList(cmd *cobra.Command, args []string) error {
+	cfg := config.Load()
+
+	entries, err := quarantine.NewStore(cfg.QuarantinePath).List()
+	if err != nil {
+		return fmt.Errorf("reading quarantine: %w", err)
+	}
+
+	if len(entries) == 0 {
+		fmt.Println("Quarantine is empty.")
+		return nil
+	}
+
+	ui.DisplayQuarantine(entries)
+	return nil
+}
+
+func runQuarantinePurge(cmd *cobra.Command, args []string) error {
+	cfg := config.Load()
+
+	age, err := utils.ParseAge(purgeOlderThan)
+	if err != nil {
+		return fmt.Errorf("--older-than: %w", err)
+	}
+
+	purged, err := quarantine.NewStore(cfg.QuarantinePath).Purge(age)
+
+	var reclaimed int64
+	for _, entry := range purged {
+		reclaimed += entry.Size
+		fmt.Printf("Purged %s (%s)\n", entry.OriginalPath, entry.ID)
+	}
+	fmt.Printf("Reclaimed %s from %d quarantined folders.\n", humanize.Bytes(uint64(reclaimed)), len(purged))
+
+	return err
+}
+
+func runRestore(cmd *cobra.Command, args []string) error {
+	cfg := config.Load()
+
+	if fromArchive != "" {
+		entry, err := archive.NewStore(fromArchive).Restore(args[0])
+		if err != nil {
+			return fmt.Errorf("restoring from archive: %w", err)
+		}
+		fmt.Printf("Restored %s (%d files, %s) from %s\n", entry.OriginalPath, entry.Files,
+			humanize.Bytes(uint64(entry.Size)), entry.Name)
+		return nil
+	}
+
+	entry, err := quarantine.NewStore(cfg.QuarantinePath).Restore(args[0])
+	if err != nil {
+		return fmt.Errorf("restoring: %w", err)
+	}
+
+	fmt.Printf("Restored %s\n", entry.OriginalPath)
+	return nil
+}
@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+
+	"github.com/d4rthvadr/node-cleaner/internal/cleaner"
+	"github.com/d4rthvadr/node-cleaner/internal/config"
+	"github.com/dustin/go-humanize"
+	"github.com/spf13/cobra"
+)
+
+var reapCmd = &cobra.Command{
+	Use:   "reap",
+	Short: "Finish deleting folders left behind by interrupted or background cleans",
+	Long: `Folders are renamed to a hidden tombstone next to their original location
+before they are deleted. reap removes tombstones whose deletion was interrupted
+or is still pending from clean --background.`,
+	RunE: runReap,
+}
+
+var reapWait bool
+
+func init() {
+	reapCmd.Flags().BoolVar(&reapWait, "wait", false, "Wait for a reap that is already running, then reap what it left, instead of exiting")
+
+	rootCmd.AddCommand(reapCmd)
+}
+
+func runReap(cmd *cobra.Command, args []string) error {
+	cfg := config.Load()
+
+	registry := cleaner.NewTombstoneRegistry(cfg.TombstonePath)
+	registry.SetWait(reapWait)
+
+	reclaimed, err := registry.Reap(cmd.Context(), appLogger)
+	if errors.Is(err, cleaner.ErrReaping) {
+		fmt.Println("Another process is already reaping tombstones.")
+		return nil
+	}
+
+	fmt.Printf("Reclaimed %s\n", humanize.Bytes(uint64(reclaimed)))
+	return err
+}
+
+// reapLeftovers starts a background reaper when tombstones from an earlier
+// run are still waiting to be deleted. Only clean runs it; the reaper
+// skips the tombstones that clean is removing itself.
+func reapLeftovers(cmd *cobra.Command, args []string) {
+	cfg := config.Load()
+	pending, err := cleaner.NewTombstoneRegistry(cfg.TombstonePath).List()
+	if err != nil {
+		appLogger.Warn("reading tombstone registry", "error", err)
+		return
+	}
+	if len(pending) == 0 {
+		return
+	}
+
+	appLogger.Info("leftover tombstones found", "count", len(pending))
+	if err := spawnReaper(); err != nil {
+		appLogger.Warn("starting background reaper", "error", err)
+	}
+}
+
+// spawnReaper runs "depo-cleaner reap --wait" detached from this process,
+// so deletion continues after the current command returns. It waits
+// rather than exits when a reaper is already busy, since that one may
+// have read the registry before this run's tombstones were added.
+func spawnReaper() error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	args := []string{"reap", "--wait"}
+	if cfgFile != "" {
+		args = append([]string{"--config", cfgFile}, args...)
+	}
+
+	reaper := exec.Command(exe, args...)
+	// a new session keeps the reaper alive when the terminal closes
+	reaper.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	if err := reaper.Start(); err != nil {
+		return err
+	}
+	appLogger.Debug("background reaper started", "pid", reaper.Process.Pid)
+	return reaper.Process.Release()
+}
@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/d4rthvadr/node-cleaner/internal/config"
+	"github.com/d4rthvadr/node-cleaner/internal/restore"
+	"github.com/d4rthvadr/node-cleaner/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var restoreHintCmd = &cobra.Command{
+	Use:   "restore-hint <project>",
+	Short: "Show how to reinstall the dependencies of a cleaned project",
+	Long: `Show how to reinstall the dependencies of a cleaned project: its package
+manager, lockfile and the exact install command recorded when it was
+cleaned. The project is a path inside it or its name; a directory shows
+every project cleaned under it. Warns when the lockfile changed since.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runRestoreHint,
+}
+
+func init() {
+	rootCmd.AddCommand(restoreHintCmd)
+}
+
+func runRestoreHint(cmd *cobra.Command, args []string) error {
+	cfg := config.Load()
+
+	hints, err := restore.NewIndex(cfg.RestorePath).Find(args[0])
+	if err != nil {
+		return fmt.Errorf("reading restore hints: %w", err)
+	}
+
+	ui.DisplayRestoreHints(hints)
+	return nil
+}
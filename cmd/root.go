@@ -5,15 +5,25 @@ package cmd
 
 import (
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 
 	"github.com/d4rthvadr/node-cleaner/internal/config"
+	"github.com/d4rthvadr/node-cleaner/internal/logger"
 	"github.com/spf13/cobra"
 )
 
 var (
-	cfgFile string
-	workers int
+	cfgFile   string
+	workers   int
+	logLevel  string
+	logFormat string
+	logStderr bool
+
+	// appLogger is shared by every command once the config is loaded
+	appLogger logger.Logger = logger.Discard()
+	logCloser io.Closer
 )
 
 // rootCmd represents the base command when called without any subcommands
@@ -26,6 +36,9 @@ var rootCmd = &cobra.Command{
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
 	err := rootCmd.Execute()
+	if logCloser != nil {
+		logCloser.Close()
+	}
 	if err != nil {
 		os.Exit(1)
 	}
@@ -39,10 +52,39 @@ func initConfig() {
 		os.Exit(1)
 	}
 
-	fmt.Println("Configuration initialized")
 	cfg := config.Load()
 	cfg.Workers = workers
 
+	// flags override the log_level and log_format config values
+	flags := rootCmd.PersistentFlags()
+	if flags.Changed("log-level") {
+		cfg.LogLevel = logLevel
+	}
+	if flags.Changed("log-format") {
+		cfg.LogFormat = logFormat
+	}
+
+	// the audit trail is read back by history, so slog output must not
+	// end up between its records
+	if filepath.Clean(cfg.LogPath) == filepath.Clean(cfg.AuditPath) {
+		fmt.Fprintf(os.Stderr, "Error initializing logger: log_path and audit_path must be different files (both are %s)\n", cfg.LogPath)
+		os.Exit(1)
+	}
+
+	log, closer, err := logger.New(logger.Options{
+		Path:   cfg.LogPath,
+		Level:  cfg.LogLevel,
+		Format: cfg.LogFormat,
+		Stderr: logStderr,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error initializing logger: %v\n", err)
+		os.Exit(1)
+	}
+	appLogger = log
+	logCloser = closer
+
+	appLogger.Debug("configuration initialized", "config", cfgFile, "log_level", cfg.LogLevel, "log_format", cfg.LogFormat)
 }
 
 func init() {
@@ -51,5 +93,8 @@ func init() {
 	// Global flags
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.depocleaner/config.yaml)")
 	rootCmd.PersistentFlags().IntVar(&workers, "workers", 4, "Number of concurrent workers")
+	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", "info", "Log level: debug, info, warn or error (default from log_level config)")
+	rootCmd.PersistentFlags().StringVar(&logFormat, "log-format", "text", "Log format: text or json (default from log_format config)")
+	rootCmd.PersistentFlags().BoolVar(&logStderr, "log-stderr", false, "Also write log records to stderr")
 
 }
@@ -3,17 +3,24 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"slices"
+	"strings"
 
 	"github.com/d4rthvadr/node-cleaner/internal/cache"
 	"github.com/d4rthvadr/node-cleaner/internal/config"
+	"github.com/d4rthvadr/node-cleaner/internal/filter"
+	"github.com/d4rthvadr/node-cleaner/internal/policy"
 	"github.com/d4rthvadr/node-cleaner/internal/scanner"
 	"github.com/d4rthvadr/node-cleaner/internal/ui"
 	"github.com/spf13/cobra"
 )
 
 var (
-	scanPath string
-	noCache  bool
+	scanPath     string
+	noCache      bool
+	scanSort     string
+	scanMinScore int
+	scanExplain  bool
 )
 
 var scanCmd = &cobra.Command{
@@ -28,6 +35,9 @@ func init() {
 	// Scan command flags
 	scanCmd.Flags().StringVarP(&scanPath, "path", "p", "", "Path to scan for dependency folders(default: $HOME)")
 	scanCmd.Flags().BoolVar(&noCache, "no-cache", false, "Disable cache")
+	scanCmd.Flags().StringVar(&scanSort, "sort", "", "Order results by score, size, age or path")
+	scanCmd.Flags().IntVar(&scanMinScore, "min-score", 0, "Only show folders whose staleness score is at least this (0-100)")
+	scanCmd.Flags().BoolVar(&scanExplain, "explain", false, "Show how each folder's staleness score was reached")
 
 	rootCmd.AddCommand(scanCmd)
 }
@@ -47,21 +57,27 @@ func runScan(cmd *cobra.Command, args []string) {
 		path = os.Getenv("HOME")
 	}
 
+	if scanSort != "" && !slices.Contains(ui.SortKeys, scanSort) {
+		fmt.Printf("unknown --sort %q (want %s)\n", scanSort, strings.Join(ui.SortKeys, ", "))
+		os.Exit(1)
+	}
+
 	cfg := config.Load()
 	cfg.ScanPath = path
-	fmt.Printf("config loaded %v", cfg)
-	fmt.Printf("properties loaded workers: %v, scanPaths: %v, cachePath: %v, logPath: %v\n", cfg.Workers, cfg.ScanPath, cfg.CachePath, cfg.LogPath)
+	appLogger.Debug("config loaded", "workers", cfg.Workers, "scan_path", cfg.ScanPath,
+		"cache_path", cfg.CachePath, "log_path", cfg.LogPath, "audit_path", cfg.AuditPath)
 
 	// Initialize cache
-	var c *cache.Cache
+	var c cache.Backend
 	var err error
 
 	if !noCache {
-		c, err = cache.NewCache(cfg.CachePath)
+		c, err = cache.Open(cfg.CacheBackend, cfg.CachePath, appLogger)
 		if err != nil {
 			fmt.Printf("failed to initialize cache: %v", err)
 			os.Exit(1)
 		}
+		defer c.Close()
 		err = c.Save() // Save cache in case unsaved changes or exit occurs
 		if err != nil {
 			fmt.Printf("failed to save cache: %v", err)
@@ -69,8 +85,16 @@ func runScan(cmd *cobra.Command, args []string) {
 		fmt.Println("Cache initialized at", cfg.CachePath)
 	}
 
+	rules, err := policy.Compile(cfg.Policies)
+	if err != nil {
+		fmt.Printf("Invalid policies: %v\n", err)
+		os.Exit(1)
+	}
+
 	// Create scanner
 	s := scanner.NewScanner(cfg, c)
+	s.SetLogger(appLogger)
+	s.SetPolicy(rules)
 
 	// Start scan
 	fmt.Printf("Starting scan on path: %s\n", path)
@@ -80,7 +104,27 @@ func runScan(cmd *cobra.Command, args []string) {
 		os.Exit(1)
 	}
 
+	if scanMinScore > 0 {
+		minScore, err := filter.Parse(filter.Options{MinScore: scanMinScore})
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		result.Folders = minScore.Apply(result.Folders)
+		result.TotalCount, result.TotalSize = len(result.Folders), 0
+		for _, f := range result.Folders {
+			result.TotalSize += f.Size
+		}
+	}
+
+	if scanSort != "" {
+		if err := ui.SortFolders(result.Folders, scanSort); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+
 	// Display results
-	ui.DisplayScanResults(result)
+	ui.DisplayScanResults(result, scanExplain)
 
 }
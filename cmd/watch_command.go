@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/d4rthvadr/node-cleaner/internal/cache"
+	"github.com/d4rthvadr/node-cleaner/internal/config"
+	"github.com/d4rthvadr/node-cleaner/internal/watcher"
+	"github.com/spf13/cobra"
+)
+
+var (
+	watchDebounce     time.Duration
+	watchPollInterval time.Duration
+)
+
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Keep the cache up to date by watching known dependency folders",
+	Long: `Watch runs until interrupted, following every dependency folder already
+in the cache (run "scan" first). Changes update or invalidate cache entries so
+the next scan is served from cache. Folders that cannot be watched because the
+system watch limit was reached are polled instead. Folders that later scans add
+to the cache are picked up every poll interval. Changes are logged; add
+--log-stderr to follow them in the terminal.`,
+	RunE: runWatch,
+}
+
+func init() {
+	watchCmd.Flags().DurationVar(&watchDebounce, "debounce", 2*time.Second, "Wait for changes to settle before re-analyzing a folder")
+	watchCmd.Flags().DurationVar(&watchPollInterval, "poll-interval", 5*time.Minute, "Polling interval for folders without a watch")
+
+	rootCmd.AddCommand(watchCmd)
+}
+
+func runWatch(cmd *cobra.Command, args []string) error {
+	cfg := config.Load()
+
+	c, err := cache.Open(cfg.CacheBackend, cfg.CachePath, appLogger)
+	if err != nil {
+		return fmt.Errorf("opening cache: %w", err)
+	}
+	defer c.Close()
+
+	w, err := watcher.NewWatcher(c, watchDebounce, watchPollInterval)
+	if err != nil {
+		return err
+	}
+	w.SetLogger(appLogger)
+
+	// stop cleanly on Ctrl+C so pending changes are saved
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	return w.Run(ctx)
+}
@@ -6,19 +6,38 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/d4rthvadr/node-cleaner/internal/gitrepo"
+	"github.com/d4rthvadr/node-cleaner/internal/logger"
 	"github.com/d4rthvadr/node-cleaner/pkg/models"
 	"github.com/d4rthvadr/node-cleaner/pkg/utils"
 )
 
-type Analyzer struct{}
+type Analyzer struct {
+	tracked *gitrepo.TrackedChecker
+	logger  logger.Logger
+}
 
 func NewAnalyzer() *Analyzer {
-	return &Analyzer{}
+	return &Analyzer{
+		tracked: gitrepo.NewTrackedChecker(),
+		logger:  logger.Discard(),
+	}
+}
+
+// SetLogger sets the logger used for analysis details
+func (a *Analyzer) SetLogger(l logger.Logger) {
+	a.logger = l
+}
+
+// IsTracked reports whether the enclosing git repository tracks files inside path
+func (a *Analyzer) IsTracked(path string) bool {
+	return a.tracked.IsTracked(path)
 }
 
 // Analyze inspects the given path and returns a DependencyFolder with its details
 func (a *Analyzer) Analyze(path string) (*models.DependencyFolder, error) {
 
+	start := time.Now()
 	info, err := os.Stat(path)
 
 	if err != nil {
@@ -30,6 +49,7 @@ func (a *Analyzer) Analyze(path string) (*models.DependencyFolder, error) {
 		AbsolutePath: path,
 		ModTime:      info.ModTime(),
 		Type:         utils.DetectType(info.Name()),
+		Tracked:      a.IsTracked(path),
 	}
 
 	// Calculate size recursively
@@ -43,6 +63,8 @@ func (a *Analyzer) Analyze(path string) (*models.DependencyFolder, error) {
 	folder.Size = size
 	folder.AccessTime = a.getAccessTime(info)
 
+	a.logger.Debug("analyzed folder", "path", path, "size", size, "tracked", folder.Tracked, "duration", time.Since(start))
+
 	return folder, nil
 }
 
@@ -63,7 +85,7 @@ func (a *Analyzer) calculateSize(path string) (int64, error) {
 	err := filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
 
 		if err != nil {
-
+			a.logger.Debug("skipping unreadable entry", "path", p, "error", err)
 			return nil // returning nil to continue walking despite the error
 		}
 
@@ -0,0 +1,470 @@
+// Package archive compresses folders into tar.gz files before they are
+// deleted, for projects that should go to cold storage rather than be lost.
+package archive
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/d4rthvadr/node-cleaner/pkg/utils"
+)
+
+// ErrNotFound is returned when no archive matches a name or path
+var ErrNotFound = errors.New("not found in archive")
+
+// Entry describes one archived folder
+type Entry struct {
+	Name         string    `json:"name"` // file name inside the archive directory
+	OriginalPath string    `json:"original_path"`
+	Size         int64     `json:"size"`       // bytes of file content archived
+	Compressed   int64     `json:"compressed"` // size of the tar.gz
+	Files        int       `json:"files"`
+	SHA256       string    `json:"sha256"`
+	ArchivedAt   time.Time `json:"archived_at"`
+}
+
+type manifest struct {
+	Entries []Entry `json:"entries"`
+}
+
+// Store writes archives and their manifest into a directory
+type Store struct {
+	dir string
+}
+
+// NewStore returns a Store keeping archives in dir
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// Dir returns the directory archives are written to
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+func (s *Store) manifestPath() string {
+	return filepath.Join(s.dir, "manifest.json")
+}
+
+// archiveName derives a readable, unique file name from the folder path,
+// e.g. /home/me/app/node_modules -> home_me_app_node_modules-20260102T150405.tar.gz
+func archiveName(abs string, at time.Time) string {
+	name := strings.Trim(filepath.ToSlash(abs), "/")
+	name = strings.NewReplacer("/", "_", " ", "-").Replace(name)
+	return fmt.Sprintf("%s-%s.tar.gz", name, at.Format("20060102T150405"))
+}
+
+// Add writes path to a tar.gz, verifies it can be read back in full and
+// records it in the manifest. The folder itself is left untouched.
+func (s *Store) Add(path string) (*Entry, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return nil, fmt.Errorf("creating archive directory: %w", err)
+	}
+
+	entry := Entry{
+		OriginalPath: abs,
+		ArchivedAt:   time.Now(),
+	}
+	entry.Name = archiveName(abs, entry.ArchivedAt)
+	dest := filepath.Join(s.dir, entry.Name)
+
+	f, err := os.CreateTemp(s.dir, entry.Name+".*.tmp")
+	if err != nil {
+		return nil, err
+	}
+	tmp := f.Name()
+
+	files, size, err := writeArchive(f, abs)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = s.verify(tmp, files, size, &entry)
+	}
+	if err == nil {
+		err = os.Rename(tmp, dest)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return nil, fmt.Errorf("archiving %s: %w", abs, err)
+	}
+
+	err = s.update(func(m *manifest) error {
+		m.Entries = append(m.Entries, entry)
+		return nil
+	})
+	if err != nil {
+		os.Remove(dest)
+		return nil, err
+	}
+
+	return &entry, nil
+}
+
+// writeArchive streams the tree at root into w as a gzip-compressed tar.
+// Entry names start with the folder's base name so restoring extracts
+// into the parent directory. Symlinks are stored as links, never followed.
+func writeArchive(w io.Writer, root string) (files int, size int64, err error) {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	base := filepath.Dir(root)
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		var link string
+		if info.Mode()&fs.ModeSymlink != 0 {
+			if link, err = os.Readlink(path); err != nil {
+				return err
+			}
+		} else if !info.Mode().IsRegular() && !info.IsDir() {
+			return nil // sockets, fifos and devices are not worth keeping
+		}
+
+		hdr, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(base, path)
+		if err != nil {
+			return err
+		}
+		hdr.Name = filepath.ToSlash(rel)
+		if info.IsDir() {
+			hdr.Name += "/"
+		}
+
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		src, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		n, err := io.Copy(tw, src)
+		src.Close()
+		if err != nil {
+			return err
+		}
+		files++
+		size += n
+		return nil
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if err := tw.Close(); err != nil {
+		return 0, 0, err
+	}
+	if err := gz.Close(); err != nil {
+		return 0, 0, err
+	}
+	return files, size, nil
+}
+
+// verify reads the archive back end to end, which checks the gzip
+// checksum, and makes sure it holds every file that was written
+func (s *Store) verify(path string, files int, size int64, entry *Entry) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	gz, err := gzip.NewReader(io.TeeReader(f, hash))
+	if err != nil {
+		return fmt.Errorf("verifying archive: %w", err)
+	}
+
+	tr := tar.NewReader(gz)
+	var gotFiles int
+	var gotSize int64
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("verifying archive: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		n, err := io.Copy(io.Discard, tr)
+		if err != nil {
+			return fmt.Errorf("verifying archive: %w", err)
+		}
+		gotFiles++
+		gotSize += n
+	}
+	// drain the gzip trailer so its checksum is verified
+	if _, err := io.Copy(io.Discard, gz); err != nil {
+		return fmt.Errorf("verifying archive: %w", err)
+	}
+	if _, err := io.Copy(hash, f); err != nil {
+		return err
+	}
+
+	if gotFiles != files || gotSize != size {
+		return fmt.Errorf("verifying archive: holds %d files (%d bytes), wrote %d files (%d bytes)",
+			gotFiles, gotSize, files, size)
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	entry.Files = files
+	entry.Size = size
+	entry.Compressed = info.Size()
+	entry.SHA256 = hex.EncodeToString(hash.Sum(nil))
+	return nil
+}
+
+// List returns all archived folders, oldest first
+func (s *Store) List() ([]Entry, error) {
+	lock, err := s.lock(false)
+	if err != nil {
+		return nil, err
+	}
+	defer lock.Release()
+
+	m, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(m.Entries, func(i, j int) bool {
+		return m.Entries[i].ArchivedAt.Before(m.Entries[j].ArchivedAt)
+	})
+	return m.Entries, nil
+}
+
+// Restore extracts the archive matching a name or original path back to
+// where the folder came from. The archive is kept.
+func (s *Store) Restore(nameOrPath string) (*Entry, error) {
+	entries, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+
+	idx := find(entries, nameOrPath)
+	if idx < 0 {
+		return nil, fmt.Errorf("%s: %w", nameOrPath, ErrNotFound)
+	}
+	entry := entries[idx]
+
+	if _, err := os.Lstat(entry.OriginalPath); err == nil {
+		return nil, fmt.Errorf("%s already exists, not overwriting it", entry.OriginalPath)
+	}
+
+	archivePath := filepath.Join(s.dir, entry.Name)
+	if err := checkSum(archivePath, entry.SHA256); err != nil {
+		return nil, err
+	}
+
+	parent := filepath.Dir(entry.OriginalPath)
+	if err := os.MkdirAll(parent, 0755); err != nil {
+		return nil, err
+	}
+
+	// extract next to the destination, then move it into place in one rename
+	staging, err := os.MkdirTemp(parent, ".depocleaner-restore-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(staging)
+
+	if err := extract(archivePath, staging); err != nil {
+		return nil, fmt.Errorf("extracting %s: %w", entry.Name, err)
+	}
+	if err := os.Rename(filepath.Join(staging, filepath.Base(entry.OriginalPath)), entry.OriginalPath); err != nil {
+		return nil, err
+	}
+
+	return &entry, nil
+}
+
+// checkSum makes sure the archive has not changed since it was written
+func checkSum(path, want string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return err
+	}
+	if got := hex.EncodeToString(hash.Sum(nil)); got != want {
+		return fmt.Errorf("%s does not match its checksum, it may be damaged", path)
+	}
+	return nil
+}
+
+// extract unpacks the archive at path into dir, refusing entries that
+// would land outside it
+func extract(path, dir string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+	tr := tar.NewReader(gz)
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(dir, filepath.FromSlash(hdr.Name))
+		if !utils.IsWithin(target, dir) || target == dir {
+			return fmt.Errorf("refusing entry %q outside the restore directory", hdr.Name)
+		}
+
+		mode := hdr.FileInfo().Mode().Perm()
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			// keep directories writable while extracting, read-only
+			// modules would otherwise block their own contents
+			if err := os.MkdirAll(target, mode|0700); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			out, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, mode)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(out, tr)
+			if closeErr := out.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			if err := os.Symlink(hdr.Linkname, target); err != nil {
+				return err
+			}
+		}
+		os.Chtimes(target, hdr.ModTime, hdr.ModTime)
+	}
+}
+
+// find returns the index of the entry matching a name or original path
+func find(entries []Entry, nameOrPath string) int {
+	abs, _ := filepath.Abs(nameOrPath)
+
+	// prefer the most recent archive when a path was archived more than once
+	for i := len(entries) - 1; i >= 0; i-- {
+		if entries[i].Name == nameOrPath || entries[i].OriginalPath == abs {
+			return i
+		}
+	}
+	return -1
+}
+
+func (s *Store) lock(exclusive bool) (*utils.FileLock, error) {
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return nil, err
+	}
+	return utils.AcquireLock(s.manifestPath()+".lock", exclusive)
+}
+
+func (s *Store) read() (*manifest, error) {
+	m := &manifest{}
+
+	data, err := os.ReadFile(s.manifestPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("reading archive manifest: %w", err)
+	}
+	return m, nil
+}
+
+// update applies fn to the manifest under an exclusive lock and writes it back
+func (s *Store) update(fn func(m *manifest) error) error {
+	lock, err := s.lock(true)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	m, err := s.read()
+	if err != nil {
+		return err
+	}
+	if err := fn(m); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(s.dir, "manifest.*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), s.manifestPath())
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
@@ -0,0 +1,87 @@
+package archive
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newFolder(t *testing.T, dir string) string {
+	t.Helper()
+	folder := filepath.Join(dir, "app", "node_modules")
+	if err := os.MkdirAll(filepath.Join(folder, "lodash"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(folder, "lodash", "index.js"), []byte(strings.Repeat("x", 4096)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(folder, ".bin"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("../lodash/index.js", filepath.Join(folder, ".bin", "lodash")); err != nil {
+		t.Fatal(err)
+	}
+	return folder
+}
+
+func TestAddAndRestore(t *testing.T) {
+	dir := t.TempDir()
+	store := NewStore(filepath.Join(dir, "archives"))
+	folder := newFolder(t, dir)
+
+	entry, err := store.Add(folder)
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if entry.Files != 1 || entry.Size != 4096 || entry.Compressed <= 0 || entry.Compressed >= entry.Size {
+		t.Errorf("entry = %+v; want 1 file of 4096 bytes compressed smaller", entry)
+	}
+	if !strings.HasSuffix(entry.Name, ".tar.gz") || !strings.Contains(entry.Name, "app_node_modules-") {
+		t.Errorf("archive name %q not derived from the path", entry.Name)
+	}
+
+	if _, err := store.Restore(folder); err == nil {
+		t.Fatal("Restore() overwrote an existing folder")
+	}
+
+	if err := os.RemoveAll(folder); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Restore(folder); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(folder, "lodash", "index.js"))
+	if err != nil || len(data) != 4096 {
+		t.Errorf("restored file = %d bytes, %v; want 4096", len(data), err)
+	}
+	if link, err := os.Readlink(filepath.Join(folder, ".bin", "lodash")); err != nil || link != "../lodash/index.js" {
+		t.Errorf("restored symlink = %q, %v", link, err)
+	}
+}
+
+func TestRestoreRejectsDamagedArchive(t *testing.T) {
+	dir := t.TempDir()
+	store := NewStore(filepath.Join(dir, "archives"))
+	folder := newFolder(t, dir)
+
+	entry, err := store.Add(folder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.RemoveAll(folder)
+
+	path := filepath.Join(store.Dir(), entry.Name)
+	if err := os.WriteFile(path, []byte("not a tarball"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Restore(entry.Name); err == nil {
+		t.Error("Restore() accepted a damaged archive")
+	}
+
+	if _, err := store.Restore("/nowhere"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Restore(unknown) error = %v; want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,202 @@
+// Package audit keeps a persistent, append-only record of every folder
+// a clean run touched, stored as JSON lines in its own file (audit_path).
+package audit
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/d4rthvadr/node-cleaner/pkg/models"
+	"github.com/d4rthvadr/node-cleaner/pkg/utils"
+)
+
+// Result values describe what happened to a folder
+const (
+	ResultDeleted     = "deleted"
+	ResultTrashed     = "trashed"
+	ResultQuarantined = "quarantined"
+	ResultArchived    = "archived"
+	ResultFailed      = "failed"
+)
+
+// Record is one folder processed by a clean run
+type Record struct {
+	RunID     string    `json:"run_id"`
+	Timestamp time.Time `json:"timestamp"`
+	User      string    `json:"user"`
+	Path      string    `json:"path"`
+	Ecosystem string    `json:"ecosystem"`
+	Size      int64     `json:"size"`
+	DryRun    bool      `json:"dry_run"`
+	Result    string    `json:"result"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// Reclaimed reports whether the record freed disk space. Archived folders
+// count, their compressed copy aside; trashed and quarantined ones are
+// only moved and still take up the same space.
+func (r Record) Reclaimed() bool {
+	return !r.DryRun && (r.Result == ResultDeleted || r.Result == ResultArchived)
+}
+
+// Moved reports whether the record moved the folder to the trash or the
+// quarantine, where it can be restored from
+func (r Record) Moved() bool {
+	return !r.DryRun && (r.Result == ResultTrashed || r.Result == ResultQuarantined)
+}
+
+// Filter narrows the records returned by Read. Zero values match everything.
+type Filter struct {
+	Since time.Time
+	Until time.Time
+	Path  string // only records at or under this path
+}
+
+func (f Filter) matches(r Record) bool {
+	if !f.Since.IsZero() && r.Timestamp.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && r.Timestamp.After(f.Until) {
+		return false
+	}
+	if f.Path != "" && !utils.IsWithin(r.Path, f.Path) {
+		return false
+	}
+	return true
+}
+
+// FromCleanResult builds the records for one clean run
+func FromCleanResult(folders []models.DependencyFolder, result *models.CleanResult) []Record {
+	now := time.Now()
+	runID := newRunID()
+	username := currentUser()
+
+	byPath := make(map[string]models.DependencyFolder, len(folders))
+	for _, f := range folders {
+		byPath[f.Path] = f
+	}
+
+	newRecord := func(path, outcome, errMsg string) Record {
+		f := byPath[path]
+		return Record{
+			RunID:     runID,
+			Timestamp: now,
+			User:      username,
+			Path:      path,
+			Ecosystem: f.Type,
+			Size:      f.Size,
+			DryRun:    result.DryRun,
+			Result:    outcome,
+			Error:     errMsg,
+		}
+	}
+
+	var records []Record
+	for _, path := range result.DeletedFolders {
+		outcome := ResultDeleted
+		if _, ok := result.QuarantineIDs[path]; ok {
+			outcome = ResultQuarantined
+		} else if _, ok := result.TrashedTo[path]; ok {
+			outcome = ResultTrashed
+		} else if _, ok := result.ArchivedTo[path]; ok {
+			outcome = ResultArchived
+		}
+		records = append(records, newRecord(path, outcome, ""))
+	}
+	for _, fail := range result.Failed {
+		records = append(records, newRecord(fail.Path, ResultFailed, fail.Reason))
+	}
+	return records
+}
+
+// Append writes records to the audit file at path, one JSON object per line
+func Append(path string, records []Record) error {
+	if len(records) == 0 {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	// serialize with other depo-cleaner processes writing the same file
+	lock, err := utils.AcquireLock(path+".lock", true)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for _, r := range records {
+		if err = enc.Encode(r); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// Read returns the audit records in the file at path that match filter,
+// oldest first. Lines that do not parse, such as one torn by a crash
+// mid-write, are skipped.
+func Read(path string, filter Filter) ([]Record, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var records []Record
+	reader := bufio.NewReader(f)
+
+	for {
+		line, err := reader.ReadBytes('\n')
+		var r Record
+		if len(line) > 0 && json.Unmarshal(line, &r) == nil && filter.matches(r) {
+			records = append(records, r)
+		}
+		if errors.Is(err, io.EOF) {
+			return records, nil
+		}
+		if err != nil {
+			return records, err
+		}
+	}
+}
+
+func currentUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return strings.TrimSpace(os.Getenv("USER"))
+}
+
+func newRunID() string {
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		return time.Now().Format("20060102T150405")
+	}
+	return hex.EncodeToString(b)
+}
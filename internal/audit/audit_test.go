@@ -0,0 +1,83 @@
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/d4rthvadr/node-cleaner/pkg/models"
+)
+
+func TestAppendAndRead(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+
+	folders := []models.DependencyFolder{
+		{Path: "/work/app/node_modules", Type: "Node.js", Size: 100},
+		{Path: "/work/api/target", Type: "Rust", Size: 200},
+		{Path: "/other/venv", Type: "Python", Size: 300},
+	}
+	result := &models.CleanResult{
+		DeletedFolders: []string{"/work/app/node_modules", "/other/venv"},
+		Failed:         []models.FailedOp{{Path: "/work/api/target", Reason: "permission denied"}},
+		TrashedTo:      map[string]string{"/other/venv": "/trash/venv"},
+	}
+
+	if err := Append(path, FromCleanResult(folders, result)); err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+
+	// a line torn by a crash mid-write must not hide the others
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	f.WriteString(`{"run_id":"abc","path":"/work/we`)
+	f.Close()
+
+	records, err := Read(path, Filter{})
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("got %d records; want 3", len(records))
+	}
+
+	results := map[string]string{}
+	for _, r := range records {
+		results[r.Path] = r.Result
+	}
+	want := map[string]string{
+		"/work/app/node_modules": ResultDeleted,
+		"/other/venv":            ResultTrashed,
+		"/work/api/target":       ResultFailed,
+	}
+	for path, outcome := range want {
+		if results[path] != outcome {
+			t.Errorf("result for %s = %q; want %q", path, results[path], outcome)
+		}
+	}
+
+	for _, r := range records {
+		if got, want := r.Reclaimed(), r.Result == ResultDeleted; got != want {
+			t.Errorf("Reclaimed() for %s = %v; want %v", r.Result, got, want)
+		}
+		if got, want := r.Moved(), r.Result == ResultTrashed; got != want {
+			t.Errorf("Moved() for %s = %v; want %v", r.Result, got, want)
+		}
+	}
+
+	work, _ := Read(path, Filter{Path: "/work"})
+	if len(work) != 2 {
+		t.Errorf("path filter returned %d records; want 2", len(work))
+	}
+
+	future, _ := Read(path, Filter{Since: time.Now().Add(time.Hour)})
+	if len(future) != 0 {
+		t.Errorf("since filter returned %d records; want 0", len(future))
+	}
+}
+
+func TestReadMissingLog(t *testing.T) {
+	records, err := Read(filepath.Join(t.TempDir(), "missing.log"), Filter{})
+	if err != nil || records != nil {
+		t.Errorf("Read() = %v, %v; want nil, nil", records, err)
+	}
+}
@@ -0,0 +1,75 @@
+package cache
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/d4rthvadr/node-cleaner/internal/logger"
+	"github.com/d4rthvadr/node-cleaner/pkg/models"
+)
+
+const (
+	// BackendJSON stores the whole index in a single JSON document (default)
+	BackendJSON = "json"
+	// BackendLog stores entries in an append-only record log
+	BackendLog = "log"
+)
+
+// Backend is the contract shared by every cache implementation.
+// It is a superset of scanner.CacheProvider so any backend can be
+// handed straight to the scanner.
+type Backend interface {
+	Get(path string) (*models.CacheEntry, bool)
+	Set(path string, entry *models.CacheEntry) error
+	Delete(path string) error
+	IsValid(path string, modTime time.Time) bool
+	Save() error
+	// Reload picks up entries other processes saved since the cache was
+	// loaded, without dropping unsaved changes
+	Reload() error
+	Clear() error
+	// Entries returns a snapshot of every live entry
+	Entries() map[string]models.CacheEntry
+	Close() error
+}
+
+// Open returns the cache backend selected by name.
+// cachePath is the configured cache_path; backends that use a different
+// on-disk format derive their file name from it.
+func Open(backend, cachePath string, log logger.Logger) (Backend, error) {
+	switch backend {
+	case "", BackendJSON:
+		return newCache(cachePath, log)
+	case BackendLog:
+		return newLogCache(LogFilePath(cachePath), log)
+	default:
+		return nil, fmt.Errorf("unknown cache backend %q (want %q or %q)", backend, BackendJSON, BackendLog)
+	}
+}
+
+// LogFilePath derives the record log location from the configured cache path,
+// e.g. ~/.depocleaner/cache.json -> ~/.depocleaner/cache.jsonl
+func LogFilePath(cachePath string) string {
+	return strings.TrimSuffix(cachePath, filepath.Ext(cachePath)) + ".jsonl"
+}
+
+// Migrate copies every entry from src into dst and persists dst.
+// It returns the number of entries copied.
+func Migrate(src, dst Backend) (int, error) {
+	entries := src.Entries()
+
+	for path, entry := range entries {
+		entry := entry
+		if err := dst.Set(path, &entry); err != nil {
+			return 0, fmt.Errorf("copying %s: %w", path, err)
+		}
+	}
+
+	if err := dst.Save(); err != nil {
+		return 0, fmt.Errorf("saving migrated cache: %w", err)
+	}
+
+	return len(entries), nil
+}
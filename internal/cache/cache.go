@@ -2,12 +2,27 @@ package cache
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"sync"
 	"time"
 
+	"github.com/d4rthvadr/node-cleaner/internal/logger"
 	"github.com/d4rthvadr/node-cleaner/pkg/models"
+	"github.com/d4rthvadr/node-cleaner/pkg/utils"
+)
+
+var (
+	// ErrCorrupt is returned when the cache file cannot be decoded
+	ErrCorrupt = errors.New("cache file is corrupt")
+	// ErrUnsupportedVersion is returned for cache files written by a newer schema
+	ErrUnsupportedVersion = errors.New("unsupported cache version")
+	// ErrNoMigration is returned for older or unknown schema versions this
+	// build cannot upgrade; such files are quarantined like corrupt ones
+	ErrNoMigration = errors.New("no migration path for cache version")
 )
 
 type Cache struct {
@@ -15,38 +30,140 @@ type Cache struct {
 	path     string
 	mu       sync.RWMutex
 	modified bool
+	// cleared is set by Clear so the next Save replaces the on-disk
+	// entries instead of merging with them
+	cleared bool
+	// deleted tracks paths removed since the last Save so merging
+	// with the on-disk index doesn't bring them back
+	deleted map[string]struct{}
+	// readOnly is set when the file on disk was written by a newer
+	// version; we never overwrite it and work from memory only
+	readOnly bool
+	logger   logger.Logger
 }
 
 func NewCache(cachePath string) (*Cache, error) {
+	return newCache(cachePath, logger.Discard())
+}
+
+func newCache(cachePath string, log logger.Logger) (*Cache, error) {
 
 	c := &Cache{
-		path: cachePath,
-		index: &models.CacheIndex{
-			Version: "1.0",
-			Entries: make(map[string]models.CacheEntry),
-		},
+		logger:  log,
+		path:    cachePath,
+		index:   newIndex(),
+		deleted: make(map[string]struct{}),
 	}
 
 	// load existing cache if available
 	// if the cache file does not exist, it's not an error
-	if err := c.load(); err != nil && !os.IsNotExist(err) {
+	err := c.load()
+	switch {
+	case err == nil:
+		c.logger.Debug("cache loaded", "path", cachePath, "entries", len(c.index.Entries), "version", c.index.Version)
+	case errors.Is(err, fs.ErrNotExist):
+		c.logger.Debug("no cache file yet", "path", cachePath)
+	case errors.Is(err, ErrCorrupt), errors.Is(err, ErrNoMigration):
+		// already quarantined by load, carry on with an empty cache
+		c.index = newIndex()
+	case errors.Is(err, ErrUnsupportedVersion):
+		c.logger.Warn("cache written by a newer version, not updating it", "path", cachePath, "error", err)
+		fmt.Fprintf(os.Stderr, "warning: %v; cache will not be updated by this version\n", err)
+		c.index = newIndex()
+		c.readOnly = true
+	default:
 		return nil, err
 	}
 
 	return c, nil
 }
 
-// load reads the cache from the specified file
+func newIndex() *models.CacheIndex {
+	return &models.CacheIndex{
+		Version: CurrentVersion,
+		Entries: make(map[string]models.CacheEntry),
+	}
+}
+
+// lockPath returns the sidecar file used for cross-process locking
+func (c *Cache) lockPath() string {
+	return c.path + ".lock"
+}
+
+// load reads the cache from the specified file while holding
+// a shared lock so it never observes a half-written file
 func (c *Cache) load() error {
-	f, err := os.Open(c.path)
+	if _, err := os.Stat(filepath.Dir(c.path)); err != nil {
+		return err
+	}
+
+	lock, err := utils.AcquireLock(c.lockPath(), false)
 	if err != nil {
 		return err
+	}
+	defer lock.Release()
+
+	index, err := readIndex(c.path)
+	if errors.Is(err, ErrCorrupt) {
+		c.quarantineCorrupt(err)
+	}
+	if err != nil {
+		return err
+	}
+
+	upgraded, err := upgradeIndex(index)
+	if errors.Is(err, ErrNoMigration) {
+		c.quarantineCorrupt(err)
+	}
+	if err != nil {
+		return err
+	}
 
+	if upgraded {
+		c.logger.Info("cache schema migrated", "path", c.path, "version", index.Version)
+	}
+
+	c.index = index
+	c.modified = upgraded // persist migrated schema on next save
+	return nil
+}
+
+// readIndex decodes the cache index stored at path
+func readIndex(path string) (*models.CacheIndex, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
 	}
 	defer f.Close()
 
-	return json.NewDecoder(f).Decode(&c.index)
+	index := &models.CacheIndex{}
+	if err := json.NewDecoder(f).Decode(index); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCorrupt, err)
+	}
+	if index.Entries == nil {
+		index.Entries = make(map[string]models.CacheEntry)
+	}
+	return index, nil
+}
+
+// quarantineCorrupt moves an undecodable or unmigratable cache file aside as
+// cache.json.corrupt-<timestamp> so it can be inspected later,
+// and warns the user instead of failing the command
+func (c *Cache) quarantineCorrupt(cause error) {
+	path := c.path
+	dest := fmt.Sprintf("%s.corrupt-%s", path, time.Now().Format("20060102T150405"))
+
+	if err := os.Rename(path, dest); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return // another process already moved it
+		}
+		c.logger.Error("could not quarantine corrupt cache", "path", path, "cause", cause, "error", err)
+		fmt.Fprintf(os.Stderr, "warning: %v; could not quarantine %s: %v\n", cause, path, err)
+		return
+	}
 
+	c.logger.Warn("quarantined corrupt cache", "path", path, "moved_to", dest, "cause", cause)
+	fmt.Fprintf(os.Stderr, "warning: %v; moved to %s and starting with an empty cache\n", cause, dest)
 }
 
 // Get retrieves a cache entry for the given path
@@ -69,6 +186,18 @@ func (c *Cache) Set(path string, entry *models.CacheEntry) error {
 	defer c.mu.Unlock()
 
 	c.index.Entries[path] = *entry
+	delete(c.deleted, path)
+	c.modified = true
+	return nil
+}
+
+// Delete removes the cache entry for the given path
+func (c *Cache) Delete(path string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.index.Entries, path)
+	c.deleted[path] = struct{}{}
 	c.modified = true
 	return nil
 }
@@ -86,41 +215,135 @@ func (c *Cache) IsValid(path string, currentModTime time.Time) bool {
 }
 
 // Save writes data from memory cache to the disk file
-// if there are modifications
+// if there are modifications.
+// An exclusive lock is held for the whole read-merge-write cycle so
+// concurrent depo-cleaner processes don't overwrite each other's entries
 func (c *Cache) Save() error {
 
-	c.mu.RLock()
-	defer c.mu.RUnlock()
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	if !c.modified {
+	if !c.modified || c.readOnly {
 		return nil // no changes to save
 	}
 
-	c.index.UpdatedAt = time.Now()
-
 	dir := filepath.Dir(c.path)
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		return err
 	}
 
-	// write to a temp file first for atomicity
-	tempPath := c.path + ".tmp"
-	f, err := os.Create(tempPath)
+	lock, err := utils.AcquireLock(c.lockPath(), true)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	// merge with whatever another process may have written since we loaded
+	if !c.cleared {
+		onDisk, err := readIndex(c.path)
+		switch {
+		case err == nil:
+			_, err := upgradeIndex(onDisk)
+			switch {
+			case errors.Is(err, ErrUnsupportedVersion):
+				// a newer build took over the cache since we loaded it
+				c.readOnly = true
+				return nil
+			case err != nil:
+				c.quarantineCorrupt(err)
+			default:
+				for path := range c.deleted {
+					delete(onDisk.Entries, path)
+				}
+				mergeEntries(c.index.Entries, onDisk.Entries)
+			}
+		case errors.Is(err, ErrCorrupt):
+			c.quarantineCorrupt(err)
+		case !errors.Is(err, fs.ErrNotExist):
+			return err
+		}
+	}
+
+	c.index.Version = CurrentVersion
+	c.index.UpdatedAt = time.Now()
+
+	// write to a uniquely named temp file first for atomicity
+	f, err := os.CreateTemp(dir, filepath.Base(c.path)+".*.tmp")
 	if err != nil {
 		return err
 	}
+	tempPath := f.Name()
 
 	encoder := json.NewEncoder(f)
 	err = encoder.Encode(c.index)
 
-	f.Close()
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
 
 	if err != nil {
+		os.Remove(tempPath)
 		return err
 	}
 
 	// replace old cache file with the new one
-	return os.Rename(tempPath, c.path)
+	if err := os.Rename(tempPath, c.path); err != nil {
+		os.Remove(tempPath)
+		return err
+	}
+
+	c.logger.Debug("cache saved", "path", c.path, "entries", len(c.index.Entries))
+
+	c.modified = false
+	c.cleared = false
+	c.deleted = make(map[string]struct{})
+	return nil
+}
+
+// Reload merges the entries on disk into memory, the way Save does, but
+// without writing anything. Paths deleted since the last Save stay deleted.
+func (c *Cache) Reload() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.cleared || c.readOnly {
+		return nil
+	}
+
+	lock, err := utils.AcquireLock(c.lockPath(), false)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	onDisk, err := readIndex(c.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := upgradeIndex(onDisk); err != nil {
+		return err // Save decides what to do about the file
+	}
+
+	for path := range c.deleted {
+		delete(onDisk.Entries, path)
+	}
+	mergeEntries(c.index.Entries, onDisk.Entries)
+	return nil
+}
+
+// mergeEntries folds entries found on disk into dst.
+// Entries only present on disk are kept; when both sides know a path
+// the most recently scanned entry wins.
+func mergeEntries(dst, onDisk map[string]models.CacheEntry) {
+	for path, diskEntry := range onDisk {
+		entry, exists := dst[path]
+		if !exists || diskEntry.LastScan.After(entry.LastScan) {
+			dst[path] = diskEntry
+		}
+	}
 }
 
 // Clear removes all entries from the cache
@@ -135,4 +358,23 @@ func (c *Cache) clearCacheFile() {
 	defer c.mu.Unlock()
 	c.index.Entries = make(map[string]models.CacheEntry)
 	c.modified = true
+	c.cleared = true
+}
+
+// Entries returns a copy of all cached entries
+func (c *Cache) Entries() map[string]models.CacheEntry {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	entries := make(map[string]models.CacheEntry, len(c.index.Entries))
+	for path, entry := range c.index.Entries {
+		entries[path] = entry
+	}
+	return entries
+}
+
+// Close is a no-op for the JSON backend, the file is only
+// held open while loading or saving
+func (c *Cache) Close() error {
+	return nil
 }
@@ -0,0 +1,231 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/d4rthvadr/node-cleaner/pkg/models"
+)
+
+func TestSaveMergesConcurrentWriters(t *testing.T) {
+	cachePath := filepath.Join(t.TempDir(), "cache.json")
+
+	first, err := NewCache(cachePath)
+	if err != nil {
+		t.Fatalf("NewCache() error = %v", err)
+	}
+	second, err := NewCache(cachePath)
+	if err != nil {
+		t.Fatalf("NewCache() error = %v", err)
+	}
+
+	now := time.Now()
+	first.Set("/a/node_modules", &models.CacheEntry{Path: "/a/node_modules", Size: 1, LastScan: now})
+	second.Set("/b/node_modules", &models.CacheEntry{Path: "/b/node_modules", Size: 2, LastScan: now})
+
+	if err := first.Save(); err != nil {
+		t.Fatalf("first.Save() error = %v", err)
+	}
+	if err := second.Save(); err != nil {
+		t.Fatalf("second.Save() error = %v", err)
+	}
+
+	reloaded, err := NewCache(cachePath)
+	if err != nil {
+		t.Fatalf("NewCache() error = %v", err)
+	}
+	for _, path := range []string{"/a/node_modules", "/b/node_modules"} {
+		if _, ok := reloaded.Get(path); !ok {
+			t.Errorf("entry %q lost after concurrent saves", path)
+		}
+	}
+}
+
+func TestSaveKeepsNewestEntry(t *testing.T) {
+	cachePath := filepath.Join(t.TempDir(), "cache.json")
+
+	stale, _ := NewCache(cachePath)
+	fresh, _ := NewCache(cachePath)
+
+	now := time.Now()
+	fresh.Set("/a/target", &models.CacheEntry{Path: "/a/target", Size: 20, LastScan: now})
+	stale.Set("/a/target", &models.CacheEntry{Path: "/a/target", Size: 10, LastScan: now.Add(-time.Hour)})
+
+	if err := fresh.Save(); err != nil {
+		t.Fatalf("fresh.Save() error = %v", err)
+	}
+	if err := stale.Save(); err != nil {
+		t.Fatalf("stale.Save() error = %v", err)
+	}
+
+	reloaded, _ := NewCache(cachePath)
+	entry, ok := reloaded.Get("/a/target")
+	if !ok || entry.Size != 20 {
+		t.Errorf("Get() = %+v, %v; want the newest entry with size 20", entry, ok)
+	}
+}
+
+func TestClearDropsOnDiskEntries(t *testing.T) {
+	cachePath := filepath.Join(t.TempDir(), "cache.json")
+
+	c, _ := NewCache(cachePath)
+	c.Set("/a/venv", &models.CacheEntry{Path: "/a/venv", LastScan: time.Now()})
+	if err := c.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	other, _ := NewCache(cachePath)
+	if err := other.Clear(); err != nil {
+		t.Fatalf("Clear() error = %v", err)
+	}
+
+	reloaded, _ := NewCache(cachePath)
+	if _, ok := reloaded.Get("/a/venv"); ok {
+		t.Error("entry survived Clear()")
+	}
+}
+
+func TestParallelSavesLeaveNoTempFiles(t *testing.T) {
+	dir := t.TempDir()
+	cachePath := filepath.Join(dir, "cache.json")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			c, err := NewCache(cachePath)
+			if err != nil {
+				t.Errorf("NewCache() error = %v", err)
+				return
+			}
+			path := filepath.Join("/p", string(rune('a'+i)))
+			c.Set(path, &models.CacheEntry{Path: path, LastScan: time.Now()})
+			if err := c.Save(); err != nil {
+				t.Errorf("Save() error = %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	reloaded, _ := NewCache(cachePath)
+	if got := len(reloaded.index.Entries); got != 8 {
+		t.Errorf("got %d entries after parallel saves; want 8", got)
+	}
+
+	matches, _ := filepath.Glob(filepath.Join(dir, "*.tmp"))
+	if len(matches) != 0 {
+		t.Errorf("temp files left behind: %v", matches)
+	}
+	if _, err := os.Stat(cachePath + ".lock"); err != nil {
+		t.Errorf("lock file missing: %v", err)
+	}
+}
+
+func TestCorruptCacheIsQuarantined(t *testing.T) {
+	dir := t.TempDir()
+	cachePath := filepath.Join(dir, "cache.json")
+	if err := os.WriteFile(cachePath, []byte(`{"version":"1.0","entries":{"/a`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := NewCache(cachePath)
+	if err != nil {
+		t.Fatalf("NewCache() error = %v; want recovery from corrupt file", err)
+	}
+	if got := len(c.Entries()); got != 0 {
+		t.Errorf("got %d entries; want fresh cache", got)
+	}
+
+	matches, _ := filepath.Glob(cachePath + ".corrupt-*")
+	if len(matches) != 1 {
+		t.Errorf("quarantined files = %v; want exactly one", matches)
+	}
+	if _, err := os.Stat(cachePath); !os.IsNotExist(err) {
+		t.Errorf("corrupt cache still in place: %v", err)
+	}
+}
+
+func TestUnversionedCacheIsMigrated(t *testing.T) {
+	cachePath := filepath.Join(t.TempDir(), "cache.json")
+	legacy := `{"entries":{"/a/node_modules":{"size":5},"":{"size":1}}}`
+	if err := os.WriteFile(cachePath, []byte(legacy), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := NewCache(cachePath)
+	if err != nil {
+		t.Fatalf("NewCache() error = %v", err)
+	}
+	if c.index.Version != CurrentVersion {
+		t.Errorf("Version = %q; want %q", c.index.Version, CurrentVersion)
+	}
+
+	entry, ok := c.Get("/a/node_modules")
+	if !ok || entry.Path != "/a/node_modules" {
+		t.Errorf("Get() = %+v, %v; want path backfilled from key", entry, ok)
+	}
+	if _, ok := c.Get(""); ok {
+		t.Error("entry with empty key survived migration")
+	}
+
+	if err := c.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	reloaded, _ := readIndex(cachePath)
+	if reloaded.Version != CurrentVersion {
+		t.Errorf("saved Version = %q; want %q", reloaded.Version, CurrentVersion)
+	}
+}
+
+func TestNewerCacheVersionIsLeftUntouched(t *testing.T) {
+	cachePath := filepath.Join(t.TempDir(), "cache.json")
+	future := []byte(`{"version":"9.0","entries":{}}`)
+	if err := os.WriteFile(cachePath, future, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := NewCache(cachePath)
+	if err != nil {
+		t.Fatalf("NewCache() error = %v", err)
+	}
+	c.Set("/a/target", &models.CacheEntry{Path: "/a/target"})
+	if err := c.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	data, _ := os.ReadFile(cachePath)
+	if string(data) != string(future) {
+		t.Errorf("newer cache file was overwritten: %s", data)
+	}
+}
+
+func TestUnknownOlderCacheVersionIsReset(t *testing.T) {
+	cachePath := filepath.Join(t.TempDir(), "cache.json")
+	if err := os.WriteFile(cachePath, []byte(`{"version":"0.3","entries":{"/old":{"path":"/old"}}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := NewCache(cachePath)
+	if err != nil {
+		t.Fatalf("NewCache() error = %v", err)
+	}
+	if c.readOnly || len(c.index.Entries) != 0 {
+		t.Fatalf("readOnly = %v, %d entries; want a fresh writable cache", c.readOnly, len(c.index.Entries))
+	}
+	if matches, _ := filepath.Glob(cachePath + ".corrupt-*"); len(matches) != 1 {
+		t.Errorf("quarantined files = %v; want the old cache moved aside", matches)
+	}
+
+	c.Set("/a/target", &models.CacheEntry{Path: "/a/target"})
+	if err := c.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	reloaded, err := readIndex(cachePath)
+	if err != nil || reloaded.Version != CurrentVersion || len(reloaded.Entries) != 1 {
+		t.Errorf("saved cache = %+v, %v; want one entry at version %s", reloaded, err, CurrentVersion)
+	}
+}
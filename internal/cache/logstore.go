@@ -0,0 +1,433 @@
+package cache
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/d4rthvadr/node-cleaner/internal/logger"
+	"github.com/d4rthvadr/node-cleaner/pkg/models"
+	"github.com/d4rthvadr/node-cleaner/pkg/utils"
+)
+
+const (
+	opSet = "set"
+	opDel = "del"
+
+	// compaction kicks in once the log holds this many records
+	// and at least half of them are superseded
+	compactMinRecords = 1024
+)
+
+// logRecord is one line of the append-only log
+type logRecord struct {
+	Op    string             `json:"op"`
+	Path  string             `json:"path"`
+	Entry *models.CacheEntry `json:"entry,omitempty"`
+}
+
+// recordPos locates the latest record of a path inside the log file
+type recordPos struct {
+	offset int64
+	length int
+}
+
+// LogCache is a cache backend built on an append-only JSON-lines log.
+// Only record offsets are kept in memory; entries are read from disk on demand,
+// and Save appends new records instead of rewriting the whole file.
+type LogCache struct {
+	path string
+	mu   sync.RWMutex
+	file *os.File
+
+	offsets map[string]recordPos // live records on disk
+	pending map[string]models.CacheEntry
+	removed map[string]struct{} // deletes not yet written
+	size    int64               // bytes of the log already replayed
+	records int                 // records in the log, live or superseded
+	logger  logger.Logger
+}
+
+// NewLogCache opens (or creates) the record log at logPath
+func NewLogCache(logPath string) (*LogCache, error) {
+	return newLogCache(logPath, logger.Discard())
+}
+
+func newLogCache(logPath string, log logger.Logger) (*LogCache, error) {
+	if err := os.MkdirAll(filepath.Dir(logPath), 0755); err != nil {
+		return nil, err
+	}
+
+	c := &LogCache{
+		path:    logPath,
+		pending: make(map[string]models.CacheEntry),
+		removed: make(map[string]struct{}),
+		logger:  log,
+	}
+
+	lock, err := utils.AcquireLock(c.lockPath(), false)
+	if err != nil {
+		return nil, err
+	}
+	defer lock.Release()
+
+	if err := c.reopen(); err != nil {
+		return nil, err
+	}
+
+	c.logger.Debug("cache log loaded", "path", logPath, "entries", len(c.offsets), "records", c.records)
+	return c, nil
+}
+
+func (c *LogCache) lockPath() string {
+	return c.path + ".lock"
+}
+
+// reopen (re)opens the log file and replays it from the start
+func (c *LogCache) reopen() error {
+	if c.file != nil {
+		c.file.Close()
+	}
+
+	f, err := os.OpenFile(c.path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+
+	c.file = f
+	c.offsets = make(map[string]recordPos)
+	c.size = 0
+	c.records = 0
+
+	return c.replay()
+}
+
+// replay reads records appended after c.size and updates the offset index.
+// A trailing line without a newline is a torn write and is left unreplayed.
+func (c *LogCache) replay() error {
+	r := bufio.NewReader(io.NewSectionReader(c.file, c.size, math.MaxInt64-c.size))
+
+	for {
+		line, err := r.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		pos := recordPos{offset: c.size, length: len(line)}
+		c.size += int64(len(line))
+		c.records++
+
+		var rec logRecord
+		if err := json.Unmarshal(line, &rec); err != nil {
+			c.logger.Warn("skipping corrupt cache record", "path", c.path, "offset", pos.offset, "error", err)
+			continue
+		}
+
+		switch rec.Op {
+		case opSet:
+			c.offsets[rec.Path] = pos
+		case opDel:
+			delete(c.offsets, rec.Path)
+		}
+	}
+}
+
+// readEntry loads the entry stored at pos
+func (c *LogCache) readEntry(pos recordPos) (*models.CacheEntry, error) {
+	buf := make([]byte, pos.length)
+	if _, err := c.file.ReadAt(buf, pos.offset); err != nil {
+		return nil, err
+	}
+
+	var rec logRecord
+	if err := json.Unmarshal(buf, &rec); err != nil {
+		return nil, err
+	}
+	if rec.Entry == nil {
+		return nil, fmt.Errorf("record for %s has no entry", rec.Path)
+	}
+	return rec.Entry, nil
+}
+
+// Get retrieves a cache entry for the given path
+func (c *LogCache) Get(path string) (*models.CacheEntry, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if entry, ok := c.pending[path]; ok {
+		return &entry, true
+	}
+	if _, ok := c.removed[path]; ok {
+		return nil, false
+	}
+
+	pos, ok := c.offsets[path]
+	if !ok {
+		return nil, false
+	}
+
+	entry, err := c.readEntry(pos)
+	if err != nil {
+		return nil, false
+	}
+	return entry, true
+}
+
+// Set buffers an entry until the next Save
+func (c *LogCache) Set(path string, entry *models.CacheEntry) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.pending[path] = *entry
+	delete(c.removed, path)
+	return nil
+}
+
+// Delete buffers the removal of an entry until the next Save
+func (c *LogCache) Delete(path string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.pending, path)
+	c.removed[path] = struct{}{}
+	return nil
+}
+
+// IsValid checks if the cache entry for the given path is still valid
+func (c *LogCache) IsValid(path string, currentModTime time.Time) bool {
+	entry, exists := c.Get(path)
+	if !exists {
+		return false
+	}
+
+	return entry.ModTime.Equal(currentModTime)
+}
+
+// Save appends buffered entries to the log under an exclusive lock.
+// Records written by other processes since the last replay are picked up
+// first, and buffered entries older than them are dropped.
+func (c *LogCache) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.pending) == 0 && len(c.removed) == 0 {
+		return nil
+	}
+
+	lock, err := utils.AcquireLock(c.lockPath(), true)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	if err := c.catchUp(); err != nil {
+		return err
+	}
+
+	// like the JSON backend, the most recently scanned entry wins: don't
+	// bury a newer record another process wrote under our older one
+	for path, entry := range c.pending {
+		pos, ok := c.offsets[path]
+		if !ok {
+			continue
+		}
+		if onDisk, err := c.readEntry(pos); err == nil && onDisk.LastScan.After(entry.LastScan) {
+			delete(c.pending, path)
+		}
+	}
+
+	var buf bytes.Buffer
+	positions := make(map[string]recordPos, len(c.pending))
+
+	for path := range c.removed {
+		line, err := json.Marshal(logRecord{Op: opDel, Path: path})
+		if err != nil {
+			return err
+		}
+		buf.Write(append(line, '\n'))
+	}
+	records := len(c.removed)
+
+	for path, entry := range c.pending {
+		entry := entry
+		line, err := json.Marshal(logRecord{Op: opSet, Path: path, Entry: &entry})
+		if err != nil {
+			return err
+		}
+		line = append(line, '\n')
+
+		positions[path] = recordPos{offset: c.size + int64(buf.Len()), length: len(line)}
+		buf.Write(line)
+	}
+
+	if _, err := c.file.WriteAt(buf.Bytes(), c.size); err != nil {
+		return err
+	}
+	if err := c.file.Sync(); err != nil {
+		return err
+	}
+
+	for path := range c.removed {
+		delete(c.offsets, path)
+	}
+	for path, pos := range positions {
+		c.offsets[path] = pos
+	}
+	c.size += int64(buf.Len())
+	c.records += records + len(positions)
+	c.pending = make(map[string]models.CacheEntry)
+	c.removed = make(map[string]struct{})
+
+	if c.records >= compactMinRecords && c.records > 2*len(c.offsets) {
+		return c.compact()
+	}
+	return nil
+}
+
+// Reload replays records other processes appended since the last replay.
+// Buffered entries stay pending until the next Save.
+func (c *LogCache) Reload() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// catchUp may truncate a torn tail, which needs the exclusive lock
+	lock, err := utils.AcquireLock(c.lockPath(), true)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	return c.catchUp()
+}
+
+// catchUp brings the in-memory index in line with the file on disk.
+// Must be called with the exclusive lock held.
+func (c *LogCache) catchUp() error {
+	onDisk, err := os.Stat(c.path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	current, err := c.file.Stat()
+	if err != nil {
+		return err
+	}
+
+	// another process compacted or cleared the log, start over
+	if onDisk == nil || !os.SameFile(onDisk, current) {
+		c.logger.Debug("cache log replaced by another process, reloading", "path", c.path)
+		return c.reopen()
+	}
+
+	if err := c.replay(); err != nil {
+		return err
+	}
+
+	// drop a torn tail so new records start on a clean line
+	if current.Size() > c.size {
+		c.logger.Warn("truncating torn cache record", "path", c.path, "bytes", current.Size()-c.size)
+		return c.file.Truncate(c.size)
+	}
+	return nil
+}
+
+// compact rewrites the log keeping only the latest record per path.
+// Must be called with the exclusive lock held.
+func (c *LogCache) compact() error {
+	c.logger.Debug("compacting cache log", "path", c.path, "records", c.records, "live", len(c.offsets))
+
+	f, err := os.CreateTemp(filepath.Dir(c.path), filepath.Base(c.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tempPath := f.Name()
+
+	w := bufio.NewWriter(f)
+	for path, pos := range c.offsets {
+		buf := make([]byte, pos.length)
+		if _, err := c.file.ReadAt(buf, pos.offset); err != nil {
+			f.Close()
+			os.Remove(tempPath)
+			return fmt.Errorf("compacting %s: %w", path, err)
+		}
+		w.Write(buf)
+	}
+
+	err = w.Flush()
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tempPath, c.path)
+	}
+	if err != nil {
+		os.Remove(tempPath)
+		return err
+	}
+
+	return c.reopen()
+}
+
+// Clear removes all entries by replacing the log with an empty one
+func (c *LogCache) Clear() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	lock, err := utils.AcquireLock(c.lockPath(), true)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	c.pending = make(map[string]models.CacheEntry)
+	c.removed = make(map[string]struct{})
+	c.offsets = make(map[string]recordPos)
+
+	return c.compact()
+}
+
+// Entries returns a snapshot of every live entry
+func (c *LogCache) Entries() map[string]models.CacheEntry {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	entries := make(map[string]models.CacheEntry, len(c.offsets)+len(c.pending))
+	for path, pos := range c.offsets {
+		if _, ok := c.removed[path]; ok {
+			continue
+		}
+		if entry, err := c.readEntry(pos); err == nil {
+			entries[path] = *entry
+		}
+	}
+	for path, entry := range c.pending {
+		entries[path] = entry
+	}
+	return entries
+}
+
+// Close releases the log file handle. Unsaved entries are discarded.
+func (c *LogCache) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.file == nil {
+		return nil
+	}
+	err := c.file.Close()
+	c.file = nil
+	return err
+}
@@ -0,0 +1,172 @@
+package cache
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/d4rthvadr/node-cleaner/internal/logger"
+	"github.com/d4rthvadr/node-cleaner/pkg/models"
+)
+
+func TestLogCacheRoundTrip(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "cache.jsonl")
+
+	c, err := NewLogCache(logPath)
+	if err != nil {
+		t.Fatalf("NewLogCache() error = %v", err)
+	}
+	modTime := time.Now().Truncate(time.Second)
+	c.Set("/a/node_modules", &models.CacheEntry{Path: "/a/node_modules", Size: 42, ModTime: modTime})
+	if err := c.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	c.Close()
+
+	reopened, err := NewLogCache(logPath)
+	if err != nil {
+		t.Fatalf("NewLogCache() error = %v", err)
+	}
+	defer reopened.Close()
+
+	entry, ok := reopened.Get("/a/node_modules")
+	if !ok || entry.Size != 42 {
+		t.Fatalf("Get() = %+v, %v; want size 42", entry, ok)
+	}
+	if !reopened.IsValid("/a/node_modules", modTime) {
+		t.Error("IsValid() = false; want true for unchanged mod time")
+	}
+}
+
+func TestLogCacheSeesOtherWriters(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "cache.jsonl")
+
+	first, _ := NewLogCache(logPath)
+	defer first.Close()
+	second, _ := NewLogCache(logPath)
+	defer second.Close()
+
+	first.Set("/a/target", &models.CacheEntry{Path: "/a/target", Size: 1})
+	second.Set("/b/target", &models.CacheEntry{Path: "/b/target", Size: 2})
+	if err := first.Save(); err != nil {
+		t.Fatalf("first.Save() error = %v", err)
+	}
+	if err := second.Save(); err != nil {
+		t.Fatalf("second.Save() error = %v", err)
+	}
+
+	if got := len(second.Entries()); got != 2 {
+		t.Errorf("second sees %d entries; want 2", got)
+	}
+}
+
+func TestLogCacheKeepsNewestEntry(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "cache.jsonl")
+
+	stale, _ := NewLogCache(logPath)
+	defer stale.Close()
+	fresh, _ := NewLogCache(logPath)
+	defer fresh.Close()
+
+	now := time.Now()
+	fresh.Set("/a/target", &models.CacheEntry{Path: "/a/target", Size: 20, LastScan: now})
+	stale.Set("/a/target", &models.CacheEntry{Path: "/a/target", Size: 10, LastScan: now.Add(-time.Hour)})
+
+	if err := fresh.Save(); err != nil {
+		t.Fatalf("fresh.Save() error = %v", err)
+	}
+	if err := stale.Save(); err != nil {
+		t.Fatalf("stale.Save() error = %v", err)
+	}
+
+	reopened, _ := NewLogCache(logPath)
+	defer reopened.Close()
+	entry, ok := reopened.Get("/a/target")
+	if !ok || entry.Size != 20 {
+		t.Errorf("Get() = %+v, %v; want the newest entry with size 20", entry, ok)
+	}
+	if entry, _ := stale.Get("/a/target"); entry == nil || entry.Size != 20 {
+		t.Errorf("stale Get() after Save = %+v; want the newest entry", entry)
+	}
+}
+
+func TestLogCacheCompaction(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "cache.jsonl")
+
+	c, _ := NewLogCache(logPath)
+	defer c.Close()
+
+	// rewrite the same few paths until compaction triggers
+	for i := 0; i < compactMinRecords; i++ {
+		path := fmt.Sprintf("/p/%d", i%4)
+		c.Set(path, &models.CacheEntry{Path: path, Size: int64(i)})
+		if err := c.Save(); err != nil {
+			t.Fatalf("Save() error = %v", err)
+		}
+	}
+
+	if c.records != 4 {
+		t.Errorf("records after compaction = %d; want 4", c.records)
+	}
+	entry, ok := c.Get("/p/3")
+	if !ok || entry.Size != compactMinRecords-1 {
+		t.Errorf("Get() = %+v, %v; want latest size %d", entry, ok, compactMinRecords-1)
+	}
+}
+
+func TestLogCacheIgnoresTornTail(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "cache.jsonl")
+
+	c, _ := NewLogCache(logPath)
+	c.Set("/a/venv", &models.CacheEntry{Path: "/a/venv", Size: 7})
+	c.Save()
+	c.Close()
+
+	f, _ := os.OpenFile(logPath, os.O_APPEND|os.O_WRONLY, 0644)
+	f.WriteString(`{"op":"set","path":"/b/ve`)
+	f.Close()
+
+	reopened, err := NewLogCache(logPath)
+	if err != nil {
+		t.Fatalf("NewLogCache() error = %v", err)
+	}
+	defer reopened.Close()
+
+	reopened.Set("/c/venv", &models.CacheEntry{Path: "/c/venv", Size: 9})
+	if err := reopened.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	entries := reopened.Entries()
+	if len(entries) != 2 {
+		t.Errorf("got %d entries; want 2 (torn record dropped)", len(entries))
+	}
+}
+
+func TestMigrateJSONToLog(t *testing.T) {
+	dir := t.TempDir()
+	cachePath := filepath.Join(dir, "cache.json")
+
+	src, _ := Open(BackendJSON, cachePath, logger.Discard())
+	src.Set("/a/node_modules", &models.CacheEntry{Path: "/a/node_modules", Size: 3})
+	src.Set("/b/node_modules", &models.CacheEntry{Path: "/b/node_modules", Size: 4})
+
+	dst, err := Open(BackendLog, cachePath, logger.Discard())
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer dst.Close()
+
+	n, err := Migrate(src, dst)
+	if err != nil || n != 2 {
+		t.Fatalf("Migrate() = %d, %v; want 2, nil", n, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "cache.jsonl")); err != nil {
+		t.Errorf("log file not written: %v", err)
+	}
+	if _, ok := dst.Get("/b/node_modules"); !ok {
+		t.Error("migrated entry missing from log backend")
+	}
+}
@@ -0,0 +1,92 @@
+package cache
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/d4rthvadr/node-cleaner/pkg/models"
+)
+
+// CurrentVersion is the cache schema version written by this build
+const CurrentVersion = "1.0"
+
+// migration upgrades an index from one schema version to the next
+type migration struct {
+	from    string
+	to      string
+	migrate func(index *models.CacheIndex) error
+}
+
+// migrations are applied in order until the index reaches CurrentVersion.
+// Append new steps here whenever the on-disk schema changes.
+var migrations = []migration{
+	{from: "", to: "1.0", migrate: migrateUnversioned},
+}
+
+// migrateUnversioned upgrades caches written before the version field was set.
+// Those could contain entries without a path, which is now the map key.
+func migrateUnversioned(index *models.CacheIndex) error {
+	for path, entry := range index.Entries {
+		if path == "" {
+			delete(index.Entries, path)
+			continue
+		}
+		if entry.Path == "" {
+			entry.Path = path
+			index.Entries[path] = entry
+		}
+	}
+	return nil
+}
+
+// upgradeIndex runs forward migrations until the index is at CurrentVersion.
+// It reports whether the index was changed. It fails with
+// ErrUnsupportedVersion for versions newer than this build understands and
+// with ErrNoMigration for older ones it has no migration for.
+func upgradeIndex(index *models.CacheIndex) (bool, error) {
+	if newerThanCurrent(index.Version) {
+		return false, fmt.Errorf("%w: %s (supported: %s)", ErrUnsupportedVersion, index.Version, CurrentVersion)
+	}
+
+	upgraded := false
+	for _, m := range migrations {
+		if index.Version != m.from {
+			continue
+		}
+		if err := m.migrate(index); err != nil {
+			return upgraded, fmt.Errorf("migrating cache from %q to %q: %w", m.from, m.to, err)
+		}
+		index.Version = m.to
+		upgraded = true
+	}
+
+	if index.Version != CurrentVersion {
+		return upgraded, fmt.Errorf("%w %q", ErrNoMigration, index.Version)
+	}
+	return upgraded, nil
+}
+
+// newerThanCurrent compares dotted numeric versions, e.g. "1.10" > "1.9"
+func newerThanCurrent(version string) bool {
+	if version == "" {
+		return false
+	}
+
+	have := strings.Split(version, ".")
+	want := strings.Split(CurrentVersion, ".")
+
+	for i := 0; i < len(have) || i < len(want); i++ {
+		var h, w int
+		if i < len(have) {
+			h, _ = strconv.Atoi(have[i])
+		}
+		if i < len(want) {
+			w, _ = strconv.Atoi(want[i])
+		}
+		if h != w {
+			return h > w
+		}
+	}
+	return false
+}
@@ -2,83 +2,415 @@ package cleaner
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"sync"
+	"time"
 
+	"github.com/d4rthvadr/node-cleaner/internal/archive"
+	"github.com/d4rthvadr/node-cleaner/internal/gitrepo"
+	"github.com/d4rthvadr/node-cleaner/internal/logger"
+	"github.com/d4rthvadr/node-cleaner/internal/marker"
+	"github.com/d4rthvadr/node-cleaner/internal/procscan"
+	"github.com/d4rthvadr/node-cleaner/internal/quarantine"
+	"github.com/d4rthvadr/node-cleaner/internal/trash"
 	"github.com/d4rthvadr/node-cleaner/pkg/models"
+	"github.com/d4rthvadr/node-cleaner/pkg/utils"
 )
 
-type Logger interface {
-	Info(msg string, fields ...interface{})
-	Error(msg string, fields ...interface{})
-}
+// Logger is satisfied by *slog.Logger
+type Logger = logger.Logger
+
+// ProgressFunc receives per-folder progress events.
+// Calls are serialized, so implementations need no locking of their own.
+type ProgressFunc func(event models.CleanEvent)
+
 type Cleaner struct {
-	dryRun bool
-	logger Logger
+	dryRun     bool
+	useTrash   bool
+	quarantine *quarantine.Store
+	archive    *archive.Store
+	workers    int
+	progress   ProgressFunc
+	progressMu sync.Mutex
+	roots      []string
+	protected  []string
+	// allowTracked permits deleting folders with git-tracked files
+	allowTracked bool
+	tracked      *gitrepo.TrackedChecker
+	// markers finds .depocleanerignore and .depocleaner.yaml protection
+	markers *marker.Finder
+	// ignoreInUse deletes folders used by running processes with a warning
+	ignoreInUse bool
+	// tombstones records folders renamed for deletion so interrupted
+	// deletions can be reaped later
+	tombstones *TombstoneRegistry
+	// background leaves tombstones to be reaped by another process
+	background bool
+	logger     Logger
 }
 
-func NewCleaner(dryRun bool, logger Logger) *Cleaner {
+func NewCleaner(dryRun bool, log Logger) *Cleaner {
+	if log == nil {
+		log = logger.Discard()
+	}
 	return &Cleaner{
-		dryRun: dryRun,
-		logger: logger,
+		dryRun:  dryRun,
+		logger:  log,
+		workers: 4,
+		tracked: gitrepo.NewTrackedChecker(),
+		markers: marker.NewFinder(func(err error) {
+			log.Warn("invalid protection marker, protecting its project", "error", err)
+		}),
 	}
 }
 
+// SetTrash makes the cleaner move folders to the freedesktop.org trash
+// instead of deleting them permanently
+func (c *Cleaner) SetTrash(enabled bool) {
+	c.useTrash = enabled
+}
+
+// SetQuarantine makes the cleaner move folders into the quarantine store
+// so they can be restored until purged. A nil store disables quarantine.
+func (c *Cleaner) SetQuarantine(store *quarantine.Store) {
+	c.quarantine = store
+}
+
+// SetArchive makes the cleaner write a verified tar.gz of each folder to
+// the store before deleting it. A nil store disables archiving.
+func (c *Cleaner) SetArchive(store *archive.Store) {
+	c.archive = store
+}
+
+// SetWorkers limits how many folders are deleted in parallel
+func (c *Cleaner) SetWorkers(n int) {
+	if n < 1 {
+		n = 1
+	}
+	c.workers = n
+}
+
+// SetAllowTracked permits deleting folders that contain files tracked by git,
+// which are refused by default since they are part of the project itself
+func (c *Cleaner) SetAllowTracked(allow bool) {
+	c.allowTracked = allow
+}
+
+// SetIgnoreInUse makes the cleaner delete folders that running processes
+// reference (with a warning) instead of skipping them
+func (c *Cleaner) SetIgnoreInUse(ignore bool) {
+	c.ignoreInUse = ignore
+}
+
+// SetTombstones records renamed folders in reg so deletions that are
+// interrupted can be finished later with Reap
+func (c *Cleaner) SetTombstones(reg *TombstoneRegistry) {
+	c.tombstones = reg
+}
+
+// SetBackground makes Clean return once folders are renamed to tombstones,
+// leaving the actual removal to a reaper. Requires a tombstone registry.
+func (c *Cleaner) SetBackground(enabled bool) {
+	c.background = enabled
+}
+
+// SetProgress registers a callback for per-folder progress events
+func (c *Cleaner) SetProgress(fn ProgressFunc) {
+	c.progress = fn
+}
+
+func (c *Cleaner) emit(event models.CleanEvent) {
+	if c.progress == nil {
+		return
+	}
+	c.progressMu.Lock()
+	defer c.progressMu.Unlock()
+	c.progress(event)
+}
+
 func (c *Cleaner) Clean(ctx context.Context, folders []models.DependencyFolder) (*models.CleanResult, error) {
 
+	start := time.Now()
+	c.logger.Info("clean started", "folders", len(folders), "dry_run", c.dryRun, "workers", c.workers,
+		"trash", c.useTrash, "quarantine", c.quarantine != nil)
+
 	result := &models.CleanResult{
-		DryRun: c.dryRun,
+		DryRun:          c.dryRun,
+		FolderDurations: make(map[string]time.Duration),
 	}
 
+	folders = c.skipInUse(folders, result)
+
 	var mu sync.Mutex
 	var wg sync.WaitGroup
 
-	for _, folder := range folders {
-		wg.Add(1)
+	workQueue := make(chan models.DependencyFolder)
 
-		go func(f models.DependencyFolder) {
+	// a fixed pool of workers keeps disk contention bounded
+	// no matter how many folders were selected
+	for i := 0; i < c.workers; i++ {
+		wg.Add(1)
+		go func() {
 			defer wg.Done()
 
-			if err := c.deleteFolder(ctx, f.Path); err != nil {
-				mu.Lock()
-				result.Failed = append(result.Failed, models.FailedOp{
-					Path:   f.Path,
-					Reason: err.Error(),
-				})
-				mu.Unlock()
-			} else {
+			for f := range workQueue {
+				folderStart := time.Now()
+				c.emit(models.CleanEvent{Kind: models.CleanStarted, Path: f.Path, Size: f.Size})
+
+				out, err := c.deleteFolder(ctx, f)
+				elapsed := time.Since(folderStart)
+
 				mu.Lock()
-				result.DeletedFolders = append(result.DeletedFolders, f.Path)
-				result.SpaceReclaimed += f.Size
+				result.FolderDurations[f.Path] = elapsed
+				if err != nil {
+					result.Failed = append(result.Failed, models.FailedOp{
+						Path:   f.Path,
+						Reason: err.Error(),
+					})
+				} else {
+					result.DeletedFolders = append(result.DeletedFolders, f.Path)
+					if out.movedTo != "" || out.tombstone != "" {
+						result.SpaceMoved += f.Size
+					} else {
+						result.SpaceReclaimed += f.Size
+					}
+					c.recordMove(result, f.Path, out)
+				}
 				mu.Unlock()
+
+				if err != nil {
+					c.logger.Error("clean failed", "path", f.Path, "size", f.Size, "duration", elapsed, "error", err)
+				} else {
+					c.logger.Info("cleaned", "path", f.Path, "size", f.Size, "duration", elapsed, "moved_to", out.movedTo)
+				}
+
+				switch {
+				case err != nil:
+					c.emit(models.CleanEvent{Kind: models.CleanFailed, Path: f.Path, Size: f.Size, Duration: elapsed, Err: err})
+				case c.dryRun:
+					c.emit(models.CleanEvent{Kind: models.CleanFinished, Path: f.Path, Size: f.Size, Duration: elapsed})
+				default:
+					c.emit(models.CleanEvent{Kind: models.CleanFinished, Path: f.Path, Size: f.Size, BytesRemoved: f.Size, Duration: elapsed})
+				}
 			}
-		}(folder)
+		}()
 	}
 
+	for _, folder := range folders {
+		workQueue <- folder
+	}
+	close(workQueue)
+
 	wg.Wait()
 
+	result.Duration = time.Since(start)
+	c.logger.Info("clean finished", "deleted", len(result.DeletedFolders), "failed", len(result.Failed),
+		"reclaimed", result.SpaceReclaimed, "moved", result.SpaceMoved, "duration", result.Duration)
 	return result, nil
 }
 
-func (c *Cleaner) deleteFolder(ctx context.Context, path string) error {
+// skipInUse drops folders referenced by running processes and records them
+// as failures, or only warns about them when in-use folders are ignored
+func (c *Cleaner) skipInUse(folders []models.DependencyFolder, result *models.CleanResult) []models.DependencyFolder {
+	paths := make([]string, len(folders))
+	for i, f := range folders {
+		paths[i] = f.Path
+	}
+
+	users, err := procscan.FindUsers(paths)
+	if errors.Is(err, procscan.ErrUnsupported) {
+		c.logger.Debug("skipping in-use check", "reason", err)
+		return folders
+	}
+	if err != nil {
+		c.logger.Warn("in-use check failed", "error", err)
+		result.Warnings = append(result.Warnings, fmt.Sprintf("could not check for running processes: %v", err))
+		return folders
+	}
+
+	var remaining []models.DependencyFolder
+	for _, f := range folders {
+		procs := users[f.Path]
+		switch {
+		case len(procs) == 0:
+			remaining = append(remaining, f)
+		case c.ignoreInUse:
+			c.logger.Warn("deleting folder in use", "path", f.Path, "processes", procscan.Describe(procs))
+			result.Warnings = append(result.Warnings,
+				fmt.Sprintf("%s is in use by %s", f.Path, procscan.Describe(procs)))
+			remaining = append(remaining, f)
+		default:
+			c.logger.Info("skipping folder in use", "path", f.Path, "processes", procscan.Describe(procs))
+			result.Failed = append(result.Failed, models.FailedOp{
+				Path:   f.Path,
+				Reason: fmt.Sprintf("in use by %s (use --ignore-in-use to delete anyway)", procscan.Describe(procs)),
+			})
+		}
+	}
+	return remaining
+}
+
+// outcome describes where a cleaned folder went
+type outcome struct {
+	movedTo   string         // quarantine id or trash location
+	tombstone string         // left for a background reaper
+	archived  *archive.Entry // archive written before deleting
+}
+
+// recordMove notes where a folder went when it was not deleted outright.
+// Must be called with the result mutex held.
+func (c *Cleaner) recordMove(result *models.CleanResult, path string, out outcome) {
+	switch {
+	case out.movedTo == "":
+	case c.quarantine != nil:
+		if result.QuarantineIDs == nil {
+			result.QuarantineIDs = make(map[string]string)
+		}
+		result.QuarantineIDs[path] = out.movedTo
+	case c.useTrash:
+		if result.TrashedTo == nil {
+			result.TrashedTo = make(map[string]string)
+		}
+		result.TrashedTo[path] = out.movedTo
+	}
+
+	if out.archived != nil {
+		if result.ArchivedTo == nil {
+			result.ArchivedTo = make(map[string]string)
+		}
+		result.ArchivedTo[path] = filepath.Join(c.archive.Dir(), out.archived.Name)
+		result.ArchivedSize += out.archived.Compressed
+	}
+
+	if out.tombstone != "" {
+		result.Tombstones = append(result.Tombstones, out.tombstone)
+	}
+}
+
+// deleteFolder removes the folder, or moves it to the quarantine or trash
+// when enabled, archiving it first if an archive store is set
+func (c *Cleaner) deleteFolder(ctx context.Context, folder models.DependencyFolder) (outcome, error) {
+	var out outcome
+	path := folder.Path
 
 	if _, err := os.Stat(path); os.IsNotExist(err) {
-		// c.logger.Info("Folder does not exist, skipping", "path", path)
-		return fmt.Errorf("path no longer exists")
+		c.logger.Info("folder does not exist, skipping", "path", path)
+		return out, fmt.Errorf("path no longer exists")
+	}
+
+	if err := c.validate(path); err != nil {
+		c.logger.Warn("refusing to delete", "path", path, "reason", err)
+		return out, err
+	}
+
+	// re-check the index as well, files may have been committed since the scan
+	if !c.allowTracked && (folder.Tracked || c.tracked.IsTracked(path)) {
+		c.logger.Warn("refusing to delete tracked folder", "path", path)
+		return out, refuse("%s contains files tracked by git (use --allow-tracked to delete anyway)", path)
+	}
+
+	// markers can be added between the scan and the clean, so look again
+	reason := folder.Protected
+	if reason == "" {
+		reason = c.markers.Protects(folder, time.Now())
+	}
+	if reason != "" {
+		c.logger.Warn("refusing to delete protected folder", "path", path, "reason", reason)
+		return out, refuse("%s", reason)
 	}
 
 	if c.dryRun {
-		// c.logger.Info("Dry run: skipping deletion", "path", path)
-		return nil
+		c.logger.Info("dry run: skipping deletion", "path", path)
+		return out, nil
 	}
 
 	select {
 	case <-ctx.Done():
-		return ctx.Err()
+		return out, ctx.Err()
 	default:
 	}
 
-	return os.RemoveAll(path)
+	if c.quarantine != nil {
+		entry, err := c.quarantine.Add(path, folder.Size)
+		if err != nil {
+			return out, fmt.Errorf("moving to quarantine: %w", err)
+		}
+		out.movedTo = entry.ID
+		return out, nil
+	}
+
+	if c.useTrash {
+		item, err := trash.Move(path)
+		if err != nil {
+			return out, fmt.Errorf("moving to trash: %w", err)
+		}
+		out.movedTo = item.TrashPath
+		return out, nil
+	}
+
+	if c.archive != nil {
+		entry, err := c.archive.Add(path)
+		if err != nil {
+			return out, err
+		}
+		c.logger.Info("archived", "path", path, "archive", entry.Name, "size", entry.Size, "compressed", entry.Compressed)
+		out.archived = entry
+	}
+
+	// rename first so the project never sees a half-deleted folder
+	tombstone, err := c.entomb(path, folder.Size)
+	if err != nil {
+		return out, fmt.Errorf("renaming for deletion: %w", err)
+	}
+	c.logger.Debug("renamed to tombstone", "path", path, "tombstone", tombstone)
+
+	if c.background && c.tombstones != nil {
+		out.tombstone = tombstone
+		return out, nil
+	}
+
+	err = utils.RemoveTree(ctx, tombstone, func(removed int64) {
+		c.emit(models.CleanEvent{Kind: models.CleanProgress, Path: path, Size: folder.Size, BytesRemoved: removed})
+	})
+	if err != nil {
+		var removeErr *utils.RemoveError
+		if errors.As(err, &removeErr) {
+			for _, f := range removeErr.Failures {
+				c.logger.Warn("could not remove entry", "folder", path, "entry", f.Path, "error", f.Err)
+			}
+		}
+		if c.tombstones != nil {
+			return out, fmt.Errorf("%w (the rest of %s will be removed by reap)", err, tombstone)
+		}
+		return out, fmt.Errorf("%w (partially removed, see %s)", err, tombstone)
+	}
+
+	if c.tombstones != nil {
+		if err := c.tombstones.forget(tombstone); err != nil {
+			c.logger.Warn("could not update tombstone registry", "tombstone", tombstone, "error", err)
+		}
+	}
+	return out, nil
+}
 
+// entomb atomically renames path to a tombstone next to it,
+// recording it in the registry when one is set
+func (c *Cleaner) entomb(path string, size int64) (string, error) {
+	if c.tombstones != nil {
+		// background tombstones are the reaper's, others are removed here
+		t, err := c.tombstones.bury(path, size, !c.background)
+		if err != nil {
+			return "", err
+		}
+		return t.Path, nil
+	}
+
+	tombstone := tombstonePath(path)
+	if err := utils.WithWritableParent(path, func() error { return os.Rename(path, tombstone) }); err != nil {
+		return "", err
+	}
+	return tombstone, nil
 }
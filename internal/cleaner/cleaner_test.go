@@ -0,0 +1,165 @@
+package cleaner
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/d4rthvadr/node-cleaner/internal/marker"
+	"github.com/d4rthvadr/node-cleaner/pkg/models"
+)
+
+// makeFolders creates n dependency folders with a file in each
+// and returns them along with the directory they were created in
+func makeFolders(t *testing.T, n int) ([]models.DependencyFolder, string) {
+	t.Helper()
+	root := t.TempDir()
+
+	folders := make([]models.DependencyFolder, n)
+	for i := range folders {
+		path := filepath.Join(root, fmt.Sprintf("app%d", i), "node_modules")
+		if err := os.MkdirAll(filepath.Join(path, "pkg"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		content := strings.Repeat("x", 100)
+		if err := os.WriteFile(filepath.Join(path, "pkg", "index.js"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		folders[i] = models.DependencyFolder{Path: path, Size: int64(len(content))}
+	}
+	return folders, root
+}
+
+func TestCleanBoundsWorkersAndReportsProgress(t *testing.T) {
+	folders, root := makeFolders(t, 12)
+
+	cl := NewCleaner(false, nil)
+	cl.SetRoots(root)
+	cl.SetWorkers(3)
+
+	active, maxActive := 0, 0
+	events := make(map[models.CleanEventKind]int)
+	cl.SetProgress(func(event models.CleanEvent) {
+		events[event.Kind]++
+		switch event.Kind {
+		case models.CleanStarted:
+			active++
+			if active > maxActive {
+				maxActive = active
+			}
+		case models.CleanFinished, models.CleanFailed:
+			active--
+		}
+	})
+
+	result, err := cl.Clean(context.Background(), folders)
+	if err != nil {
+		t.Fatalf("Clean() error = %v", err)
+	}
+
+	if maxActive > 3 {
+		t.Errorf("%d folders deleted concurrently; want at most 3", maxActive)
+	}
+	if events[models.CleanStarted] != 12 || events[models.CleanFinished] != 12 {
+		t.Errorf("events = %v; want 12 started and 12 finished", events)
+	}
+	if len(result.DeletedFolders) != 12 || result.SpaceReclaimed != 1200 {
+		t.Errorf("deleted %d folders, reclaimed %d; want 12 and 1200", len(result.DeletedFolders), result.SpaceReclaimed)
+	}
+	if result.Duration <= 0 {
+		t.Error("Duration not set")
+	}
+	for _, f := range folders {
+		if _, ok := result.FolderDurations[f.Path]; !ok {
+			t.Errorf("no duration recorded for %s", f.Path)
+		}
+		if _, err := os.Stat(f.Path); !os.IsNotExist(err) {
+			t.Errorf("%s still exists", f.Path)
+		}
+	}
+}
+
+func TestCleanReportsFailures(t *testing.T) {
+	folders, root := makeFolders(t, 1)
+	missing := models.DependencyFolder{Path: filepath.Join(root, "gone", "node_modules")}
+
+	cl := NewCleaner(false, nil)
+	cl.SetRoots(root)
+
+	var failed []string
+	cl.SetProgress(func(event models.CleanEvent) {
+		if event.Kind == models.CleanFailed {
+			failed = append(failed, event.Path)
+		}
+	})
+
+	result, _ := cl.Clean(context.Background(), append(folders, missing))
+
+	if len(result.Failed) != 1 || result.Failed[0].Path != missing.Path {
+		t.Errorf("Failed = %+v; want only the missing folder", result.Failed)
+	}
+	if len(failed) != 1 || failed[0] != missing.Path {
+		t.Errorf("failed events for %v; want %s", failed, missing.Path)
+	}
+}
+
+func TestCleanRefusesMarkedProjects(t *testing.T) {
+	folders, root := makeFolders(t, 2)
+	// a marker added after the scan still protects app0
+	if err := os.WriteFile(filepath.Join(root, "app0", marker.IgnoreFile), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cl := NewCleaner(false, nil)
+	cl.SetRoots(root)
+
+	result, _ := cl.Clean(context.Background(), folders)
+
+	if len(result.Failed) != 1 || result.Failed[0].Path != folders[0].Path {
+		t.Fatalf("Failed = %+v; want only %s", result.Failed, folders[0].Path)
+	}
+	if !strings.Contains(result.Failed[0].Reason, marker.IgnoreFile) {
+		t.Errorf("Reason = %q; want it to name the marker", result.Failed[0].Reason)
+	}
+	if _, err := os.Stat(folders[0].Path); err != nil {
+		t.Errorf("protected folder removed: %v", err)
+	}
+	if len(result.DeletedFolders) != 1 {
+		t.Errorf("deleted %v; want only %s", result.DeletedFolders, folders[1].Path)
+	}
+}
+
+func TestCleanUnderReadOnlyParent(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("permission bits do not restrict root")
+	}
+
+	for _, registry := range []bool{false, true} {
+		folders, root := makeFolders(t, 1)
+		parent := filepath.Dir(folders[0].Path)
+		if err := os.Chmod(parent, 0555); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { os.Chmod(parent, 0755) })
+
+		cl := NewCleaner(false, nil)
+		cl.SetRoots(root)
+		if registry {
+			cl.SetTombstones(NewTombstoneRegistry(filepath.Join(t.TempDir(), "tombstones.json")))
+		}
+
+		result, err := cl.Clean(context.Background(), folders)
+		if err != nil || len(result.Failed) > 0 {
+			t.Fatalf("registry %v: Clean() = %+v, %v; want the folder deleted", registry, result.Failed, err)
+		}
+		if _, err := os.Stat(folders[0].Path); !os.IsNotExist(err) {
+			t.Errorf("registry %v: %s still exists", registry, folders[0].Path)
+		}
+		if info, _ := os.Stat(parent); info.Mode().Perm() != 0555 {
+			t.Errorf("registry %v: parent mode = %v; want it restored to 0555", registry, info.Mode().Perm())
+		}
+	}
+}
@@ -0,0 +1,41 @@
+//go:build linux
+
+package cleaner
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCleanSkipsFoldersInUse(t *testing.T) {
+	folders, root := makeFolders(t, 1)
+
+	cmd := exec.Command("sleep", "30")
+	cmd.Dir = folders[0].Path
+	if err := cmd.Start(); err != nil {
+		t.Skipf("cannot start helper process: %v", err)
+	}
+	defer cmd.Process.Kill()
+	time.Sleep(100 * time.Millisecond) // let the child exec
+
+	cl := NewCleaner(false, nil)
+	cl.SetRoots(root)
+
+	result, _ := cl.Clean(context.Background(), folders)
+	if len(result.Failed) != 1 || !strings.Contains(result.Failed[0].Reason, "in use by sleep") {
+		t.Fatalf("Failed = %+v; want folder skipped as in use by sleep", result.Failed)
+	}
+	if _, err := os.Stat(folders[0].Path); err != nil {
+		t.Errorf("in-use folder was deleted: %v", err)
+	}
+
+	cl.SetIgnoreInUse(true)
+	result, _ = cl.Clean(context.Background(), folders)
+	if len(result.DeletedFolders) != 1 || len(result.Warnings) != 1 {
+		t.Errorf("deleted %v with warnings %v; want deletion with one warning", result.DeletedFolders, result.Warnings)
+	}
+}
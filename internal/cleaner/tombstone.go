@@ -0,0 +1,243 @@
+package cleaner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/d4rthvadr/node-cleaner/pkg/utils"
+)
+
+// ErrReaping is returned by Reap when another process is already reaping
+var ErrReaping = errors.New("tombstones are already being reaped by another process")
+
+// Tombstone is a folder renamed aside for deletion that has not been
+// fully removed yet
+type Tombstone struct {
+	Path         string    `json:"path"`
+	OriginalPath string    `json:"original_path"`
+	Size         int64     `json:"size"`
+	CreatedAt    time.Time `json:"created_at"`
+	// Owner is the pid of the process removing the tombstone itself, or 0
+	// when it is left for a reaper
+	Owner int `json:"owner,omitempty"`
+}
+
+// TombstoneRegistry records tombstones on disk so deletions interrupted by
+// a crash, Ctrl+C or a background run can be finished later by Reap
+type TombstoneRegistry struct {
+	path string
+	// wait makes Reap queue behind a running reaper instead of giving up
+	wait bool
+}
+
+// NewTombstoneRegistry returns a registry stored at path
+// (e.g. ~/.depocleaner/tombstones.json)
+func NewTombstoneRegistry(path string) *TombstoneRegistry {
+	return &TombstoneRegistry{path: path}
+}
+
+// SetWait makes Reap wait for a reaper that is already running and then
+// reap whatever was buried since, instead of returning ErrReaping. The
+// reaper a clean starts waits, so its tombstones are never left behind.
+func (r *TombstoneRegistry) SetWait(wait bool) {
+	r.wait = wait
+}
+
+// tombstonePath returns a hidden name next to path, on the same
+// filesystem, so renaming to it is atomic
+func tombstonePath(path string) string {
+	name := "." + filepath.Base(path) + utils.TombstoneMarker + strconv.FormatInt(time.Now().UnixNano(), 36)
+	return filepath.Join(filepath.Dir(path), name)
+}
+
+// bury renames path to a tombstone and records it. The rename happens
+// under the registry lock so a concurrent Reap never sees the entry
+// before the tombstone exists. An owned tombstone is left alone by
+// reapers for as long as this process runs.
+func (r *TombstoneRegistry) bury(path string, size int64, owned bool) (*Tombstone, error) {
+	t := &Tombstone{
+		Path:         tombstonePath(path),
+		OriginalPath: path,
+		Size:         size,
+		CreatedAt:    time.Now(),
+	}
+	if owned {
+		t.Owner = os.Getpid()
+	}
+
+	err := r.update(func(entries []Tombstone) ([]Tombstone, error) {
+		if err := utils.WithWritableParent(path, func() error { return os.Rename(path, t.Path) }); err != nil {
+			return entries, err
+		}
+		return append(entries, *t), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// forget drops the tombstone at path from the registry
+func (r *TombstoneRegistry) forget(path string) error {
+	return r.update(func(entries []Tombstone) ([]Tombstone, error) {
+		kept := entries[:0]
+		for _, t := range entries {
+			if t.Path != path {
+				kept = append(kept, t)
+			}
+		}
+		return kept, nil
+	})
+}
+
+// List returns the recorded tombstones, oldest first
+func (r *TombstoneRegistry) List() ([]Tombstone, error) {
+	lock, err := r.lock(false)
+	if err != nil {
+		return nil, err
+	}
+	defer lock.Release()
+
+	return r.read()
+}
+
+// Reap deletes every recorded tombstone and returns the bytes reclaimed.
+// Entries whose tombstone is already gone are dropped, and tombstones a
+// running clean is still removing itself are skipped. Only one process
+// reaps at a time; others get ErrReaping unless they wait (see SetWait).
+func (r *TombstoneRegistry) Reap(ctx context.Context, log Logger) (int64, error) {
+	if err := os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return 0, err
+	}
+	var reapLock *utils.FileLock
+	var err error
+	if r.wait {
+		reapLock, err = utils.AcquireLock(r.path+".reap.lock", true)
+	} else {
+		reapLock, err = utils.TryLock(r.path + ".reap.lock")
+	}
+	if err != nil {
+		return 0, err
+	}
+	if reapLock == nil {
+		return 0, ErrReaping
+	}
+	defer reapLock.Release()
+
+	tombstones, err := r.List()
+	if err != nil {
+		return 0, err
+	}
+
+	var reclaimed int64
+	var errs []error
+
+	for _, t := range tombstones {
+		if err := ctx.Err(); err != nil {
+			return reclaimed, err
+		}
+
+		if t.Owner != 0 && t.Owner != os.Getpid() && processAlive(t.Owner) {
+			log.Debug("skipping tombstone removed by a running clean", "path", t.Path, "pid", t.Owner)
+			continue
+		}
+
+		// never remove anything the registry did not rename itself
+		if !utils.IsTombstone(filepath.Base(t.Path)) {
+			log.Warn("ignoring registry entry that is not a tombstone", "path", t.Path)
+			errs = append(errs, r.forget(t.Path))
+			continue
+		}
+
+		start := time.Now()
+		if err := utils.RemoveTree(ctx, t.Path, nil); err != nil {
+			log.Error("reaping tombstone failed", "path", t.Path, "original_path", t.OriginalPath, "error", err)
+			errs = append(errs, fmt.Errorf("%s: %w", t.OriginalPath, err))
+			continue
+		}
+		log.Info("reaped tombstone", "path", t.Path, "original_path", t.OriginalPath, "size", t.Size,
+			"duration", time.Since(start))
+
+		reclaimed += t.Size
+		errs = append(errs, r.forget(t.Path))
+	}
+
+	return reclaimed, errors.Join(errs...)
+}
+
+// processAlive reports whether a process with this pid exists
+func processAlive(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
+
+func (r *TombstoneRegistry) lock(exclusive bool) (*utils.FileLock, error) {
+	if err := os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return nil, err
+	}
+	return utils.AcquireLock(r.path+".lock", exclusive)
+}
+
+func (r *TombstoneRegistry) read() ([]Tombstone, error) {
+	var entries []Tombstone
+
+	data, err := os.ReadFile(r.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("reading tombstone registry: %w", err)
+	}
+	return entries, nil
+}
+
+// update applies fn to the registry under an exclusive lock and writes
+// the result back atomically
+func (r *TombstoneRegistry) update(fn func(entries []Tombstone) ([]Tombstone, error)) error {
+	lock, err := r.lock(true)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	entries, err := r.read()
+	if err != nil {
+		return err
+	}
+
+	entries, err = fn(entries)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(r.path), filepath.Base(r.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), r.path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
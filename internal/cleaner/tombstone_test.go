@@ -0,0 +1,178 @@
+package cleaner
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/d4rthvadr/node-cleaner/pkg/utils"
+)
+
+func TestBackgroundCleanLeavesTombstonesForReap(t *testing.T) {
+	folders, root := makeFolders(t, 3)
+	reg := NewTombstoneRegistry(filepath.Join(t.TempDir(), "tombstones.json"))
+
+	cl := NewCleaner(false, nil)
+	cl.SetRoots(root)
+	cl.SetTombstones(reg)
+	cl.SetBackground(true)
+
+	result, err := cl.Clean(context.Background(), folders)
+	if err != nil {
+		t.Fatalf("Clean() error = %v", err)
+	}
+	if len(result.DeletedFolders) != 3 || len(result.Tombstones) != 3 {
+		t.Fatalf("deleted %d, tombstones %d; want 3 and 3", len(result.DeletedFolders), len(result.Tombstones))
+	}
+	if result.SpaceReclaimed != 0 || result.SpaceMoved != 300 {
+		t.Errorf("reclaimed %d, moved %d; want 0 and 300 until the tombstones are reaped", result.SpaceReclaimed, result.SpaceMoved)
+	}
+
+	for _, f := range folders {
+		if _, err := os.Stat(f.Path); !os.IsNotExist(err) {
+			t.Errorf("%s still exists at its original path", f.Path)
+		}
+	}
+	for _, tomb := range result.Tombstones {
+		if !utils.IsTombstone(filepath.Base(tomb)) || !utils.IsWithin(tomb, root) {
+			t.Errorf("unexpected tombstone %s", tomb)
+		}
+	}
+
+	pending, err := reg.List()
+	if err != nil || len(pending) != 3 {
+		t.Fatalf("List() = %d entries, %v; want 3", len(pending), err)
+	}
+
+	reclaimed, err := reg.Reap(context.Background(), cl.logger)
+	if err != nil {
+		t.Fatalf("Reap() error = %v", err)
+	}
+	if reclaimed != 300 {
+		t.Errorf("reclaimed %d; want 300", reclaimed)
+	}
+	for _, tomb := range result.Tombstones {
+		if _, err := os.Stat(tomb); !os.IsNotExist(err) {
+			t.Errorf("tombstone %s was not reaped", tomb)
+		}
+	}
+	if pending, _ := reg.List(); len(pending) != 0 {
+		t.Errorf("registry still holds %d entries after reap", len(pending))
+	}
+}
+
+func TestInterruptedCleanLeavesNoHalfDeletedFolder(t *testing.T) {
+	folders, root := makeFolders(t, 1)
+	reg := NewTombstoneRegistry(filepath.Join(t.TempDir(), "tombstones.json"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cl := NewCleaner(false, nil)
+	cl.SetRoots(root)
+	cl.SetTombstones(reg)
+
+	// rename, then cancel before removal finishes
+	tomb, err := cl.entomb(folders[0].Path, folders[0].Size)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	if err := utils.RemoveTree(ctx, tomb, nil); err == nil {
+		t.Fatal("utils.RemoveTree() succeeded after cancel")
+	}
+
+	if _, err := os.Stat(folders[0].Path); !os.IsNotExist(err) {
+		t.Errorf("%s should be gone from its original path", folders[0].Path)
+	}
+
+	if _, err := reg.Reap(context.Background(), cl.logger); err != nil {
+		t.Fatalf("Reap() error = %v", err)
+	}
+	if _, err := os.Stat(tomb); !os.IsNotExist(err) {
+		t.Errorf("tombstone %s survived reap", tomb)
+	}
+}
+
+func TestReapSkipsTombstonesOwnedByRunningCleans(t *testing.T) {
+	folders, _ := makeFolders(t, 2)
+	reg := NewTombstoneRegistry(filepath.Join(t.TempDir(), "tombstones.json"))
+
+	owned, err := reg.bury(folders[0].Path, folders[0].Size, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	left, err := reg.bury(folders[1].Path, folders[1].Size, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// pretend a clean that is still running (our parent) owns the first one
+	if err := reg.update(func(entries []Tombstone) ([]Tombstone, error) {
+		for i := range entries {
+			if entries[i].Path == owned.Path {
+				entries[i].Owner = os.Getppid()
+			}
+		}
+		return entries, nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := reg.Reap(context.Background(), NewCleaner(false, nil).logger); err != nil {
+		t.Fatalf("Reap() error = %v", err)
+	}
+	if _, err := os.Stat(owned.Path); err != nil {
+		t.Errorf("tombstone owned by a running clean was reaped: %v", err)
+	}
+	if _, err := os.Stat(left.Path); !os.IsNotExist(err) {
+		t.Errorf("tombstone %s left for the reaper survived", left.Path)
+	}
+	if pending, _ := reg.List(); len(pending) != 1 || pending[0].Path != owned.Path {
+		t.Errorf("registry = %+v; want only the owned tombstone", pending)
+	}
+}
+
+func TestWaitingReapRunsAfterTheCurrentOne(t *testing.T) {
+	folders, _ := makeFolders(t, 1)
+	reg := NewTombstoneRegistry(filepath.Join(t.TempDir(), "tombstones.json"))
+	tomb, err := reg.bury(folders[0].Path, folders[0].Size, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// another reaper is busy
+	running, err := utils.TryLock(reg.path + ".reap.lock")
+	if err != nil || running == nil {
+		t.Fatalf("TryLock() = %v, %v", running, err)
+	}
+
+	log := NewCleaner(false, nil).logger
+	if _, err := reg.Reap(context.Background(), log); !errors.Is(err, ErrReaping) {
+		t.Fatalf("Reap() error = %v; want ErrReaping", err)
+	}
+
+	reg.SetWait(true)
+	done := make(chan error, 1)
+	go func() {
+		_, err := reg.Reap(context.Background(), log)
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		t.Fatalf("waiting Reap() returned %v while another reaper held the lock", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	running.Release()
+	if err := <-done; err != nil {
+		t.Fatalf("Reap() error = %v", err)
+	}
+	if _, err := os.Stat(tomb.Path); !os.IsNotExist(err) {
+		t.Errorf("tombstone %s was not reaped", tomb.Path)
+	}
+}
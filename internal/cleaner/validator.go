@@ -0,0 +1,111 @@
+package cleaner
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/d4rthvadr/node-cleaner/pkg/utils"
+)
+
+// ErrRefused marks folders the cleaner declined to touch for safety reasons
+var ErrRefused = errors.New("refused")
+
+func refuse(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrRefused, fmt.Sprintf(format, args...))
+}
+
+// SetRoots records the scan roots. Only folders strictly inside one of
+// them may be deleted; with no roots set every folder is refused.
+func (c *Cleaner) SetRoots(roots ...string) {
+	c.roots = nil
+	for _, root := range roots {
+		if abs, err := filepath.Abs(root); err == nil {
+			c.roots = append(c.roots, abs)
+		}
+	}
+}
+
+// SetProtected sets paths that must never be deleted, nor anything
+// inside them or containing them
+func (c *Cleaner) SetProtected(paths []string) {
+	c.protected = nil
+	for _, p := range paths {
+		if abs, err := filepath.Abs(os.ExpandEnv(p)); err == nil {
+			c.protected = append(c.protected, abs)
+		}
+	}
+}
+
+// validate re-checks a folder right before it is deleted, since the
+// filesystem may have changed between the scan and the confirmation
+func (c *Cleaner) validate(path string) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+
+	info, err := os.Lstat(abs)
+	if err != nil {
+		return err
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		return refuse("%s is now a symlink", abs)
+	}
+	if !info.IsDir() {
+		return refuse("%s is no longer a directory", abs)
+	}
+
+	if !utils.IsTargetDirectory(filepath.Base(abs)) {
+		return refuse("%s does not match a dependency folder", abs)
+	}
+
+	if abs == string(filepath.Separator) {
+		return refuse("%s is the filesystem root", abs)
+	}
+	if home, err := os.UserHomeDir(); err == nil && utils.IsWithin(home, abs) {
+		return refuse("%s is the home directory or contains it", abs)
+	}
+
+	for _, root := range c.roots {
+		if utils.IsWithin(root, abs) {
+			return refuse("%s is the scan root %s or contains it", abs, root)
+		}
+	}
+
+	// resolve the parent so a project directory swapped for a symlink
+	// can't redirect the delete outside the scanned roots
+	resolved := abs
+	if parent, err := filepath.EvalSymlinks(filepath.Dir(abs)); err == nil {
+		resolved = filepath.Join(parent, filepath.Base(abs))
+	}
+	if !c.insideRoots(abs) || !c.insideRoots(resolved) {
+		return refuse("%s is outside the scanned paths", abs)
+	}
+
+	for _, protected := range c.protected {
+		if utils.IsWithin(abs, protected) || utils.IsWithin(protected, abs) {
+			return refuse("%s is protected by %s", abs, protected)
+		}
+	}
+
+	return nil
+}
+
+// insideRoots reports whether path lies strictly inside one of the scan roots,
+// comparing against both the roots as given and with symlinks resolved
+func (c *Cleaner) insideRoots(path string) bool {
+	for _, root := range c.roots {
+		candidates := []string{root}
+		if resolved, err := filepath.EvalSymlinks(root); err == nil && resolved != root {
+			candidates = append(candidates, resolved)
+		}
+		for _, r := range candidates {
+			if path != r && utils.IsWithin(path, r) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+package cleaner
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+
+	mkdir := func(path string) string {
+		if err := os.MkdirAll(path, 0755); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	ok := mkdir(filepath.Join(root, "app", "node_modules"))
+	notTarget := mkdir(filepath.Join(root, "app", "src"))
+	protected := mkdir(filepath.Join(root, "keep", "vendor"))
+	outsideFolder := mkdir(filepath.Join(outside, "other", "node_modules"))
+
+	symlinked := filepath.Join(root, "linked", "node_modules")
+	mkdir(filepath.Dir(symlinked))
+	if err := os.Symlink(outsideFolder, symlinked); err != nil {
+		t.Fatal(err)
+	}
+
+	// a project directory replaced by a symlink pointing outside the roots
+	redirected := filepath.Join(root, "moved")
+	if err := os.Symlink(filepath.Join(outside, "other"), redirected); err != nil {
+		t.Fatal(err)
+	}
+
+	cl := NewCleaner(false, nil)
+	cl.SetRoots(root)
+	cl.SetProtected([]string{filepath.Join(root, "keep")})
+
+	tests := []struct {
+		name    string
+		path    string
+		refused bool
+	}{
+		{name: "Dependency folder inside root", path: ok},
+		{name: "Folder no longer matches a detector", path: notTarget, refused: true},
+		{name: "Folder replaced by a symlink", path: symlinked, refused: true},
+		{name: "Parent replaced by a symlink", path: filepath.Join(redirected, "node_modules"), refused: true},
+		{name: "Folder outside the scanned roots", path: outsideFolder, refused: true},
+		{name: "Protected folder", path: protected, refused: true},
+		{name: "Scan root itself", path: root, refused: true},
+		{name: "Filesystem root", path: "/", refused: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := cl.validate(tt.path)
+			if tt.refused && !errors.Is(err, ErrRefused) {
+				t.Errorf("validate(%q) = %v; want ErrRefused", tt.path, err)
+			}
+			if !tt.refused && err != nil {
+				t.Errorf("validate(%q) = %v; want nil", tt.path, err)
+			}
+		})
+	}
+}
+
+func TestValidateWithoutRootsRefuses(t *testing.T) {
+	folders, _ := makeFolders(t, 1)
+
+	if err := NewCleaner(false, nil).validate(folders[0].Path); !errors.Is(err, ErrRefused) {
+		t.Errorf("validate() without roots = %v; want ErrRefused", err)
+	}
+}
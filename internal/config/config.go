@@ -18,6 +18,7 @@ func Init(cfgFile string) error {
 
 	if cfgFile != "" {
 		configPath = cfgFile
+		viper.SetConfigFile(cfgFile)
 	} else {
 
 		home, err := os.UserHomeDir()
@@ -81,10 +82,20 @@ func setDefaults() {
 
 	viper.SetDefault("scan_path", home)
 	viper.SetDefault("cache_path", filepath.Join(configDir, "cache.json"))
+	viper.SetDefault("cache_backend", "json")
 	viper.SetDefault("log_path", filepath.Join(configDir, "depocleaner.log"))
+	viper.SetDefault("audit_path", filepath.Join(configDir, "history.jsonl"))
+	viper.SetDefault("log_level", "info")
+	viper.SetDefault("log_format", "text")
 	viper.SetDefault("follow_symlinks", false)
 	viper.SetDefault("max_depth", 10)
 	viper.SetDefault("workers", 4)
+	viper.SetDefault("use_trash", false)
+	viper.SetDefault("quarantine_path", filepath.Join(configDir, "quarantine"))
+	viper.SetDefault("tombstone_path", filepath.Join(configDir, "tombstones.json"))
+	viper.SetDefault("restore_path", filepath.Join(configDir, "restore.json"))
+	viper.SetDefault("protected_paths", []string{})
+	viper.SetDefault("policies", []map[string]interface{}{})
 	// TODO: allow user to customize or add additional ignore paths
 	viper.SetDefault("ignore_paths", []string{
 		"/System",
@@ -111,7 +122,17 @@ func Load() *models.Config {
 		home, _ := os.UserHomeDir()
 		configDir := filepath.Join(home, ".depocleaner")
 		globalConfig.CachePath = filepath.Join(configDir, "cache.json")
+		globalConfig.CacheBackend = viper.GetString("cache_backend")
+		globalConfig.UseTrash = viper.GetBool("use_trash")
+		globalConfig.QuarantinePath = viper.GetString("quarantine_path")
+		globalConfig.TombstonePath = viper.GetString("tombstone_path")
+		globalConfig.RestorePath = viper.GetString("restore_path")
+		globalConfig.ProtectedPaths = viper.GetStringSlice("protected_paths")
+		viper.UnmarshalKey("policies", &globalConfig.Policies)
 		globalConfig.LogPath = filepath.Join(configDir, "depocleaner.log")
+		globalConfig.AuditPath = viper.GetString("audit_path")
+		globalConfig.LogLevel = viper.GetString("log_level")
+		globalConfig.LogFormat = viper.GetString("log_format")
 	}
 	return globalConfig
 
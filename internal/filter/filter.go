@@ -0,0 +1,133 @@
+// Package filter selects dependency folders by age, size, ecosystem,
+// path and staleness score, for cleaning without the interactive selector.
+package filter
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/d4rthvadr/node-cleaner/internal/staleness"
+	"github.com/d4rthvadr/node-cleaner/pkg/models"
+	"github.com/d4rthvadr/node-cleaner/pkg/utils"
+	"github.com/dustin/go-humanize"
+)
+
+// Filter holds the criteria a folder must meet; zero values match everything
+type Filter struct {
+	OlderThan time.Duration // last activity at least this long ago
+	MinSize   int64
+	Types     []string // ecosystem names, e.g. node, rust
+	PathGlobs []string // folder must match at least one
+	Excludes  []string // folder must match none
+	MinScore  int      // staleness score, 0 to 100
+}
+
+// Options are the raw command line values a Filter is parsed from
+type Options struct {
+	OlderThan string
+	MinSize   string
+	Types     []string
+	PathGlobs []string
+	Excludes  []string
+	MinScore  int
+}
+
+// Parse validates opts and builds a Filter
+func Parse(opts Options) (*Filter, error) {
+	f := &Filter{
+		PathGlobs: opts.PathGlobs,
+		Excludes:  opts.Excludes,
+		MinScore:  opts.MinScore,
+	}
+
+	if opts.MinScore < 0 || opts.MinScore > staleness.MaxScore {
+		return nil, fmt.Errorf("--min-score: %d is outside 0-%d", opts.MinScore, staleness.MaxScore)
+	}
+
+	if opts.OlderThan != "" {
+		age, err := utils.ParseAge(opts.OlderThan)
+		if err != nil {
+			return nil, fmt.Errorf("--older-than: %w", err)
+		}
+		f.OlderThan = age
+	}
+
+	if opts.MinSize != "" {
+		size, err := humanize.ParseBytes(opts.MinSize)
+		if err != nil {
+			return nil, fmt.Errorf("--min-size: %w", err)
+		}
+		f.MinSize = int64(size)
+	}
+
+	for _, t := range opts.Types {
+		t = strings.TrimSpace(t)
+		if t == "" {
+			continue
+		}
+		if !utils.IsKnownEcosystem(t) {
+			return nil, fmt.Errorf("--type: unknown ecosystem %q (want node, python, rust, go or php)", t)
+		}
+		f.Types = append(f.Types, t)
+	}
+
+	return f, nil
+}
+
+// IsEmpty reports whether the filter has no criteria at all
+func (f *Filter) IsEmpty() bool {
+	return f.OlderThan == 0 && f.MinSize == 0 && len(f.Types) == 0 &&
+		len(f.PathGlobs) == 0 && len(f.Excludes) == 0 && f.MinScore == 0
+}
+
+// Match reports whether folder meets every criterion
+func (f *Filter) Match(folder models.DependencyFolder, now time.Time) bool {
+	if f.OlderThan > 0 && now.Sub(LastActivity(folder)) < f.OlderThan {
+		return false
+	}
+	if folder.Size < f.MinSize || folder.Score < f.MinScore {
+		return false
+	}
+	if len(f.Types) > 0 && !matchesAny(f.Types, func(t string) bool {
+		return utils.MatchesEcosystem(folder.Type, t)
+	}) {
+		return false
+	}
+	if len(f.PathGlobs) > 0 && !matchesAny(f.PathGlobs, func(g string) bool {
+		return utils.MatchGlob(g, folder.Path)
+	}) {
+		return false
+	}
+	return !matchesAny(f.Excludes, func(g string) bool {
+		return utils.MatchGlob(g, folder.Path)
+	})
+}
+
+// Apply returns the folders that match f
+func (f *Filter) Apply(folders []models.DependencyFolder) []models.DependencyFolder {
+	now := time.Now()
+
+	var matched []models.DependencyFolder
+	for _, folder := range folders {
+		if f.Match(folder, now) {
+			matched = append(matched, folder)
+		}
+	}
+	return matched
+}
+
+// LastActivity is when the folder was last changed. Access times are not
+// used: measuring a folder's size reads it, so scans keep refreshing them.
+func LastActivity(folder models.DependencyFolder) time.Time {
+	return folder.ModTime
+}
+
+func matchesAny(values []string, match func(string) bool) bool {
+	for _, v := range values {
+		if match(v) {
+			return true
+		}
+	}
+	return false
+}
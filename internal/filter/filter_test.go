@@ -0,0 +1,73 @@
+package filter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/d4rthvadr/node-cleaner/pkg/models"
+)
+
+func TestMatch(t *testing.T) {
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	old := models.DependencyFolder{
+		Path:    "/home/dev/clients/acme/node_modules",
+		Type:    "Node.js",
+		Size:    500 << 20,
+		ModTime: now.AddDate(0, -6, 0),
+	}
+	recent := models.DependencyFolder{
+		Path:       "/home/dev/work/api/target",
+		Type:       "Rust",
+		Size:       50 << 20,
+		ModTime:    now.AddDate(-1, 0, 0),
+		AccessTime: now.AddDate(0, 0, -2),
+	}
+
+	tests := []struct {
+		name   string
+		opts   Options
+		folder models.DependencyFolder
+		want   bool
+	}{
+		{"Empty filter matches", Options{}, recent, true},
+		{"Older than", Options{OlderThan: "90d"}, old, true},
+		{"Access time is ignored", Options{OlderThan: "90d"}, recent, true},
+		{"Recently changed", Options{OlderThan: "90d"}, models.DependencyFolder{ModTime: now.AddDate(0, 0, -2)}, false},
+		{"Min size", Options{MinSize: "200MB"}, old, true},
+		{"Below min size", Options{MinSize: "200MB"}, recent, false},
+		{"Type listed", Options{Types: []string{"node", "rust"}}, recent, true},
+		{"Type not listed", Options{Types: []string{"python"}}, old, false},
+		{"Path glob", Options{PathGlobs: []string{"clients/**"}}, old, true},
+		{"Path glob miss", Options{PathGlobs: []string{"clients/**"}}, recent, false},
+		{"Excluded", Options{Excludes: []string{"acme"}}, old, false},
+		{"Min score", Options{MinScore: 60}, models.DependencyFolder{Score: 72}, true},
+		{"Below min score", Options{MinScore: 60}, models.DependencyFolder{Score: 40}, false},
+		{"All criteria", Options{OlderThan: "30d", MinSize: "100MB", Types: []string{"node"}, Excludes: []string{"work"}}, old, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := Parse(tt.opts)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if got := f.Match(tt.folder, now); got != tt.want {
+				t.Errorf("Match(%s) = %v; want %v", tt.folder.Path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRejectsBadValues(t *testing.T) {
+	for _, opts := range []Options{
+		{OlderThan: "soon"},
+		{MinSize: "big"},
+		{Types: []string{"cobol"}},
+		{MinScore: 101},
+	} {
+		if _, err := Parse(opts); err == nil {
+			t.Errorf("Parse(%+v) succeeded; want error", opts)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package gitrepo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// IndexEntry is a file tracked in the git index along with its stat cache
+type IndexEntry struct {
+	Path    string // slash separated, relative to the work tree
+	Mode    uint32
+	Size    uint32
+	ModTime time.Time
+	// Stage is non-zero for the sides of an unresolved merge conflict
+	Stage int
+	// NoCheck is set for assume-unchanged and skip-worktree entries,
+	// whose work tree files git doesn't compare against the index
+	NoCheck bool
+}
+
+// Index is the parsed content of .git/index
+type Index struct {
+	Entries []IndexEntry // sorted by path
+}
+
+// ReadIndex parses the repository's index file (versions 2, 3 and 4).
+// A repository without an index (nothing staged yet) yields an empty Index.
+func (r *Repo) ReadIndex() (*Index, error) {
+	data, err := os.ReadFile(filepath.Join(r.GitDir, "index"))
+	if errors.Is(err, os.ErrNotExist) {
+		return &Index{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return parseIndex(data, r.hashSize())
+}
+
+func parseIndex(data []byte, hashSize int) (*Index, error) {
+	if len(data) < 12 || string(data[:4]) != "DIRC" {
+		return nil, errors.New("not a git index file")
+	}
+
+	version := binary.BigEndian.Uint32(data[4:8])
+	if version < 2 || version > 4 {
+		return nil, fmt.Errorf("unsupported git index version %d", version)
+	}
+	count := int(binary.BigEndian.Uint32(data[8:12]))
+
+	// fixed part: ctime, mtime (8 bytes each), 6 uint32 stat fields, hash, flags
+	fixed := 40 + hashSize + 2
+
+	index := &Index{Entries: make([]IndexEntry, 0, count)}
+	pos := 12
+	prevPath := ""
+
+	for i := 0; i < count; i++ {
+		start := pos
+		if pos+fixed > len(data) {
+			return nil, errors.New("truncated git index")
+		}
+
+		entry := IndexEntry{
+			ModTime: time.Unix(int64(binary.BigEndian.Uint32(data[pos+8:])), int64(binary.BigEndian.Uint32(data[pos+12:]))),
+			Mode:    binary.BigEndian.Uint32(data[pos+24:]),
+			Size:    binary.BigEndian.Uint32(data[pos+36:]),
+		}
+
+		flags := binary.BigEndian.Uint16(data[pos+40+hashSize:])
+		pos += fixed
+		entry.Stage = int(flags>>12) & 3
+		entry.NoCheck = flags&0x8000 != 0
+
+		// extended flags (skip-worktree, intent-to-add) add two bytes in v3+
+		if version >= 3 && flags&0x4000 != 0 {
+			if pos+2 > len(data) {
+				return nil, errors.New("truncated git index")
+			}
+			entry.NoCheck = entry.NoCheck || binary.BigEndian.Uint16(data[pos:])&0x4000 != 0
+			pos += 2
+		}
+
+		if version == 4 {
+			strip, n := readOffset(data[pos:])
+			if n == 0 || strip > len(prevPath) {
+				return nil, errors.New("corrupt git index path prefix")
+			}
+			pos += n
+
+			end := bytes.IndexByte(data[pos:], 0)
+			if end < 0 {
+				return nil, errors.New("truncated git index")
+			}
+			entry.Path = prevPath[:len(prevPath)-strip] + string(data[pos:pos+end])
+			pos += end + 1
+		} else {
+			end := bytes.IndexByte(data[pos:], 0)
+			if end < 0 {
+				return nil, errors.New("truncated git index")
+			}
+			entry.Path = string(data[pos : pos+end])
+
+			// entries are NUL padded to a multiple of 8 bytes
+			entryLen := pos + end - start + 1
+			pos = start + (entryLen+7)/8*8
+		}
+
+		prevPath = entry.Path
+		index.Entries = append(index.Entries, entry)
+	}
+
+	return index, nil
+}
+
+// readOffset decodes git's variable length offset encoding used by index v4
+func readOffset(data []byte) (int, int) {
+	if len(data) == 0 {
+		return 0, 0
+	}
+
+	c := data[0]
+	val := int(c & 0x7f)
+	n := 1
+	for c&0x80 != 0 {
+		if n >= len(data) {
+			return 0, 0
+		}
+		c = data[n]
+		n++
+		val = ((val + 1) << 7) | int(c&0x7f)
+	}
+	return val, n
+}
+
+// HasTrackedUnder reports whether any tracked file lives under dir,
+// given as a slash separated path relative to the work tree
+func (idx *Index) HasTrackedUnder(dir string) bool {
+	prefix := strings.TrimSuffix(dir, "/") + "/"
+
+	i := sort.Search(len(idx.Entries), func(i int) bool {
+		return idx.Entries[i].Path >= prefix
+	})
+	return i < len(idx.Entries) && strings.HasPrefix(idx.Entries[i].Path, prefix)
+}
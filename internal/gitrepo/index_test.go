@@ -0,0 +1,103 @@
+package gitrepo
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// initRepo creates a repository with the given files committed, using the
+// git binary only to produce fixtures for the native reader
+func initRepo(t *testing.T, indexVersion string, files ...string) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git binary not available")
+	}
+
+	dir := t.TempDir()
+	for _, f := range files {
+		path := filepath.Join(dir, f)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(f), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"config", "index.version", indexVersion},
+		{"add", "."},
+		{"-c", "user.name=t", "-c", "user.email=t@t", "commit", "-qm", "init"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	return dir
+}
+
+func TestReadIndexVersions(t *testing.T) {
+	files := []string{
+		"package.json",
+		"vendor/github.com/pkg/errors/errors.go",
+		"vendor/modules.txt",
+		"web/node_modules.md",
+		"web/src/index.js",
+	}
+
+	for _, version := range []string{"2", "3", "4"} {
+		t.Run("v"+version, func(t *testing.T) {
+			dir := initRepo(t, version, files...)
+
+			repo, err := Find(filepath.Join(dir, "web", "src"))
+			if err != nil || repo == nil {
+				t.Fatalf("Find() = %v, %v", repo, err)
+			}
+
+			idx, err := repo.ReadIndex()
+			if err != nil {
+				t.Fatalf("ReadIndex() error = %v", err)
+			}
+			if len(idx.Entries) != len(files) {
+				t.Fatalf("got %d entries; want %d", len(idx.Entries), len(files))
+			}
+			for i, entry := range idx.Entries {
+				if entry.Path != files[i] {
+					t.Errorf("entry %d = %q; want %q", i, entry.Path, files[i])
+				}
+			}
+
+			if !idx.HasTrackedUnder("vendor") {
+				t.Error("HasTrackedUnder(vendor) = false; want true")
+			}
+			if idx.HasTrackedUnder("web/node_modules") {
+				t.Error("HasTrackedUnder(web/node_modules) = true; want false")
+			}
+		})
+	}
+}
+
+func TestTrackedChecker(t *testing.T) {
+	dir := initRepo(t, "2", "go.mod", "vendor/modules.txt")
+
+	untracked := filepath.Join(dir, "web", "node_modules")
+	if err := os.MkdirAll(untracked, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	checker := NewTrackedChecker()
+	if !checker.IsTracked(filepath.Join(dir, "vendor")) {
+		t.Error("committed vendor folder reported as untracked")
+	}
+	if checker.IsTracked(untracked) {
+		t.Error("untracked node_modules reported as tracked")
+	}
+	if checker.IsTracked(t.TempDir()) {
+		t.Error("folder outside any repository reported as tracked")
+	}
+}
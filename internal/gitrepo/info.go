@@ -0,0 +1,125 @@
+package gitrepo
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/d4rthvadr/node-cleaner/pkg/models"
+)
+
+// gitlinkMode is the index mode of a submodule entry
+const gitlinkMode = 0160000
+
+// Dirty reports whether tracked files in the work tree differ from the
+// index, using the stat data git caches there: a file that is missing or
+// whose size or mtime changed counts as modified, as does an unresolved
+// merge conflict. Untracked files are not considered.
+func (r *Repo) Dirty() (bool, error) {
+	idx, err := r.ReadIndex()
+	if err != nil {
+		return false, err
+	}
+
+	for _, e := range idx.Entries {
+		if e.Stage != 0 {
+			return true, nil
+		}
+		if e.NoCheck || e.Mode == gitlinkMode {
+			continue
+		}
+
+		info, err := os.Lstat(filepath.Join(r.WorkTree, filepath.FromSlash(e.Path)))
+		if err != nil {
+			return true, nil // deleted, or replaced by something unreadable
+		}
+		// the index stores the low 32 bits of the size
+		if uint32(info.Size()) != e.Size || !sameTime(info.ModTime(), e.ModTime) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// sameTime compares an mtime with one cached in the index, which only
+// has nanoseconds when git was built to record them
+func sameTime(mtime, cached time.Time) bool {
+	if cached.Nanosecond() == 0 {
+		return mtime.Unix() == cached.Unix()
+	}
+	return mtime.Equal(cached)
+}
+
+// RemoteURL returns the URL of the current branch's upstream remote,
+// falling back to origin and then to the first remote configured.
+// It returns "" for repositories without remotes.
+func (r *Repo) RemoteURL() (string, error) {
+	cfg, err := r.Config()
+	if err != nil {
+		return "", err
+	}
+
+	branch, _, _ := r.Head()
+	for _, remote := range []string{cfg["branch."+branch+".remote"], "origin"} {
+		if url := cfg["remote."+remote+".url"]; remote != "" && url != "" {
+			return url, nil
+		}
+	}
+
+	var keys []string
+	for key := range cfg {
+		if strings.HasPrefix(key, "remote.") && strings.HasSuffix(key, ".url") {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return "", nil
+	}
+	sort.Strings(keys)
+	return cfg[keys[0]], nil
+}
+
+// Info collects the repository's metadata. Parts that can't be read are
+// left empty rather than failing the whole lookup.
+func (r *Repo) Info() *models.GitInfo {
+	info := &models.GitInfo{Root: r.WorkTree}
+	info.Branch, _, _ = r.Head()
+	info.LastCommit, _ = r.LastCommit()
+	info.Dirty, _ = r.Dirty()
+	info.Unpushed, _ = r.Unpushed()
+	info.RemoteURL, _ = r.RemoteURL()
+	return info
+}
+
+// InfoCache looks up the repository metadata of folders, reading each
+// repository once. Safe for concurrent use.
+type InfoCache struct {
+	mu    sync.Mutex
+	infos map[string]*models.GitInfo // by work tree
+}
+
+func NewInfoCache() *InfoCache {
+	return &InfoCache{infos: make(map[string]*models.GitInfo)}
+}
+
+// Lookup returns the metadata of the repository enclosing path, or nil
+// when path is not inside a readable repository
+func (c *InfoCache) Lookup(path string) *models.GitInfo {
+	repo, err := Find(path)
+	if err != nil || repo == nil {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if info, ok := c.infos[repo.WorkTree]; ok {
+		return info
+	}
+	info := repo.Info()
+	c.infos[repo.WorkTree] = info
+	return info
+}
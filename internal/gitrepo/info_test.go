@@ -0,0 +1,68 @@
+package gitrepo
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestInfo(t *testing.T) {
+	dir := initRepo(t, "2", "package.json", "src/index.js")
+	git(t, dir, "remote", "add", "upstream", "https://example.com/other.git")
+	git(t, dir, "remote", "add", "origin", "git@example.com:me/app.git")
+
+	cache := NewInfoCache()
+	info := cache.Lookup(filepath.Join(dir, "src"))
+	if info == nil {
+		t.Fatal("Lookup() = nil; want repository info")
+	}
+
+	if info.Root != dir {
+		t.Errorf("Root = %q; want %q", info.Root, dir)
+	}
+	if want := git(t, dir, "rev-parse", "--abbrev-ref", "HEAD"); info.Branch != want {
+		t.Errorf("Branch = %q; want %q", info.Branch, want)
+	}
+	if time.Since(info.LastCommit) > time.Hour {
+		t.Errorf("LastCommit = %v; want about now", info.LastCommit)
+	}
+	if info.RemoteURL != "git@example.com:me/app.git" {
+		t.Errorf("RemoteURL = %q; want origin's URL", info.RemoteURL)
+	}
+	if info.Dirty {
+		t.Error("Dirty = true for a fresh clone")
+	}
+	if cache.Lookup(dir) != info {
+		t.Error("Lookup() read the same repository twice")
+	}
+
+	repo, _ := Find(dir)
+	steps := []struct {
+		name   string
+		change func(t *testing.T)
+		want   bool
+	}{
+		{"Untracked file", func(t *testing.T) { write(t, filepath.Join(dir, "notes.txt"), "x") }, false},
+		{"Modified file", func(t *testing.T) { write(t, filepath.Join(dir, "src", "index.js"), "changed") }, true},
+		{"Staged", func(t *testing.T) { git(t, dir, "add", ".") }, false},
+		{"Deleted file", func(t *testing.T) { os.Remove(filepath.Join(dir, "package.json")) }, true},
+	}
+	for _, step := range steps {
+		step.change(t)
+		got, err := repo.Dirty()
+		if err != nil {
+			t.Fatalf("%s: Dirty() error = %v", step.name, err)
+		}
+		if got != step.want {
+			t.Errorf("%s: Dirty() = %v; want %v", step.name, got, step.want)
+		}
+	}
+}
+
+func write(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,249 @@
+package gitrepo
+
+import (
+	"bufio"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Head returns the branch HEAD points at and the commit it resolves to.
+// branch is empty when HEAD is detached; hash is empty on an unborn branch.
+func (r *Repo) Head() (branch, hash string, err error) {
+	data, err := os.ReadFile(filepath.Join(r.GitDir, "HEAD"))
+	if err != nil {
+		return "", "", err
+	}
+
+	head := strings.TrimSpace(string(data))
+	ref, ok := strings.CutPrefix(head, "ref:")
+	if !ok {
+		return "", head, nil
+	}
+
+	ref = strings.TrimSpace(ref)
+	hash, err = r.ResolveRef(ref)
+	return strings.TrimPrefix(ref, "refs/heads/"), hash, err
+}
+
+// ResolveRef returns the commit a full ref name (refs/heads/main) points
+// at, looking at loose refs first and packed-refs second. It returns ""
+// for refs that don't exist.
+func (r *Repo) ResolveRef(name string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(r.commonDir(), filepath.FromSlash(name)))
+	if err == nil {
+		return strings.TrimSpace(string(data)), nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return "", err
+	}
+
+	f, err := os.Open(filepath.Join(r.commonDir(), "packed-refs"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// "<hash> <ref>", with "#" headers and "^<hash>" peeled tag lines
+		hash, ref, ok := strings.Cut(scanner.Text(), " ")
+		if ok && ref == name {
+			return hash, nil
+		}
+	}
+	return "", scanner.Err()
+}
+
+// ReflogEntry is one line of a reflog
+type ReflogEntry struct {
+	Old, New string
+	Time     time.Time
+	Message  string
+}
+
+// Reflog reads the reflog of a ref ("HEAD" or a full ref name), oldest
+// entry first. Refs without a reflog yield no entries.
+func (r *Repo) Reflog(ref string) ([]ReflogEntry, error) {
+	dir := r.commonDir()
+	if ref == "HEAD" {
+		dir = r.GitDir // HEAD's reflog is per worktree
+	}
+
+	f, err := os.Open(filepath.Join(dir, "logs", filepath.FromSlash(ref)))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []ReflogEntry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if entry, ok := parseReflogLine(scanner.Text()); ok {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, scanner.Err()
+}
+
+// parseReflogLine parses "<old> <new> Name <email> <unix> <tz>\t<message>"
+func parseReflogLine(line string) (ReflogEntry, bool) {
+	header, message, _ := strings.Cut(line, "\t")
+	fields := strings.Fields(header)
+	if len(fields) < 4 {
+		return ReflogEntry{}, false
+	}
+
+	sec, err := strconv.ParseInt(fields[len(fields)-2], 10, 64)
+	if err != nil {
+		return ReflogEntry{}, false
+	}
+	return ReflogEntry{
+		Old:     fields[0],
+		New:     fields[1],
+		Time:    time.Unix(sec, 0),
+		Message: message,
+	}, true
+}
+
+// LastCommit is when the current branch last moved (a commit, merge,
+// pull or reset) according to its reflog, falling back to commits in
+// HEAD's reflog and then to when the branch's ref, or packed-refs, was
+// last written.
+func (r *Repo) LastCommit() (time.Time, error) {
+	branch, _, err := r.Head()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if branch != "" {
+		entries, err := r.Reflog("refs/heads/" + branch)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if len(entries) > 0 {
+			return entries[len(entries)-1].Time, nil
+		}
+	}
+
+	entries, err := r.Reflog("HEAD")
+	if err != nil {
+		return time.Time{}, err
+	}
+	for i := len(entries) - 1; i >= 0; i-- {
+		if strings.HasPrefix(entries[i].Message, "commit") {
+			return entries[i].Time, nil
+		}
+	}
+
+	return r.refWritten(branch), nil
+}
+
+// refWritten is when the branch's loose ref or packed-refs was last
+// written, or the zero time when neither exists
+func (r *Repo) refWritten(branch string) time.Time {
+	var paths []string
+	if branch != "" {
+		paths = append(paths, filepath.Join(r.commonDir(), "refs", "heads", filepath.FromSlash(branch)))
+	}
+	paths = append(paths, filepath.Join(r.commonDir(), "packed-refs"))
+
+	for _, path := range paths {
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return info.ModTime()
+		}
+	}
+	return time.Time{}
+}
+
+// Unpushed reports whether the current branch has commits that were never
+// seen on its upstream. Branches without an upstream count as unpushed,
+// since their commits only exist in this clone. A detached HEAD or an
+// unborn branch has nothing to push.
+func (r *Repo) Unpushed() (bool, error) {
+	branch, hash, err := r.Head()
+	if err != nil || branch == "" || hash == "" {
+		return false, err
+	}
+
+	cfg, err := r.Config()
+	if err != nil {
+		return false, err
+	}
+	remote := cfg["branch."+branch+".remote"]
+	merge := cfg["branch."+branch+".merge"]
+	if remote == "" || merge == "" {
+		return true, nil
+	}
+
+	upstream := "refs/remotes/" + remote + "/" + strings.TrimPrefix(merge, "refs/heads/")
+	upstreamHash, err := r.ResolveRef(upstream)
+	if err != nil {
+		return false, err
+	}
+	if upstreamHash == hash {
+		return false, nil
+	}
+
+	// the upstream may have moved on since: the commit was pushed if the
+	// remote-tracking ref ever pointed at it
+	entries, err := r.Reflog(upstream)
+	if err != nil {
+		return false, err
+	}
+	for _, e := range entries {
+		if e.New == hash {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// Config reads the repository's config file into a map keyed like
+// `git config` keys: section.subsection.name, with section and name
+// lowercased. Later values of a repeated key win.
+func (r *Repo) Config() (map[string]string, error) {
+	f, err := os.Open(filepath.Join(r.commonDir(), "config"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	cfg := make(map[string]string)
+	section := ""
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			// [section] or [section "subsection"]
+			name, sub, ok := strings.Cut(line[1:len(line)-1], " ")
+			section = strings.ToLower(strings.TrimSpace(name))
+			if ok {
+				section += "." + strings.Trim(strings.TrimSpace(sub), `"`)
+			}
+			continue
+		}
+
+		key, value, _ := strings.Cut(line, "=")
+		cfg[section+"."+strings.ToLower(strings.TrimSpace(key))] = strings.Trim(strings.TrimSpace(value), `"`)
+	}
+	return cfg, scanner.Err()
+}
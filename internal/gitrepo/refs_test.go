@@ -0,0 +1,72 @@
+package gitrepo
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func git(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-c", "user.name=t", "-c", "user.email=t@t"}, args...)...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func TestHeadAndUnpushed(t *testing.T) {
+	dir := initRepo(t, "2", "package.json")
+	remote := filepath.Join(t.TempDir(), "remote.git")
+	git(t, dir, "init", "-q", "--bare", remote)
+
+	repo, err := Find(dir)
+	if err != nil || repo == nil {
+		t.Fatalf("Find() = %v, %v", repo, err)
+	}
+
+	branch, hash, err := repo.Head()
+	if err != nil {
+		t.Fatalf("Head() error = %v", err)
+	}
+	if want := git(t, dir, "rev-parse", "--abbrev-ref", "HEAD"); branch != want {
+		t.Errorf("branch = %q; want %q", branch, want)
+	}
+	if want := git(t, dir, "rev-parse", "HEAD"); hash != want {
+		t.Errorf("hash = %q; want %q", hash, want)
+	}
+
+	if last, err := repo.LastCommit(); err != nil || time.Since(last) > time.Hour {
+		t.Errorf("LastCommit() = %v, %v; want about now", last, err)
+	}
+
+	steps := []struct {
+		name string
+		git  [][]string
+		want bool
+	}{
+		{"No upstream", nil, true},
+		{"Pushed", [][]string{{"remote", "add", "origin", remote}, {"push", "-q", "-u", "origin", "HEAD"}}, false},
+		{"Packed refs", [][]string{{"pack-refs", "--all"}}, false},
+		{"New commit", [][]string{{"commit", "-q", "--allow-empty", "-m", "wip"}}, true},
+		{"Pushed again", [][]string{{"push", "-q"}}, false},
+		{"Upstream moved on", [][]string{{"reset", "-q", "--hard", "HEAD~1"}}, false},
+	}
+
+	for _, step := range steps {
+		for _, args := range step.git {
+			git(t, dir, args...)
+		}
+		got, err := repo.Unpushed()
+		if err != nil {
+			t.Fatalf("%s: Unpushed() error = %v", step.name, err)
+		}
+		if got != step.want {
+			t.Errorf("%s: Unpushed() = %v; want %v", step.name, got, step.want)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+// Package gitrepo reads git repository metadata directly from the .git
+// directory, without shelling out to the git binary.
+package gitrepo
+
+import (
+	"bufio"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Repo locates a git work tree and its git directory
+type Repo struct {
+	WorkTree string // directory containing .git
+	GitDir   string // the .git directory (resolved for worktrees and submodules)
+}
+
+// Find walks up from path looking for the enclosing repository.
+// It returns nil, nil when path is not inside a repository.
+func Find(path string) (*Repo, error) {
+	dir, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		dotGit := filepath.Join(dir, ".git")
+		info, err := os.Stat(dotGit)
+
+		switch {
+		case err == nil && info.IsDir():
+			return &Repo{WorkTree: dir, GitDir: dotGit}, nil
+		case err == nil:
+			// worktrees and submodules use a "gitdir: <path>" file
+			gitDir, err := readGitFile(dotGit)
+			if err != nil {
+				return nil, err
+			}
+			return &Repo{WorkTree: dir, GitDir: gitDir}, nil
+		case !errors.Is(err, fs.ErrNotExist):
+			return nil, err
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, nil
+		}
+		dir = parent
+	}
+}
+
+// readGitFile resolves the git directory referenced by a .git file
+func readGitFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	line := strings.TrimSpace(string(data))
+	gitDir, ok := strings.CutPrefix(line, "gitdir:")
+	if !ok {
+		return "", errors.New("malformed .git file: " + path)
+	}
+
+	gitDir = strings.TrimSpace(gitDir)
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(filepath.Dir(path), gitDir)
+	}
+	return filepath.Clean(gitDir), nil
+}
+
+// hashSize returns the object id length used by the repository,
+// 32 bytes for sha256 repositories and 20 for the default sha1
+func (r *Repo) hashSize() int {
+	f, err := os.Open(filepath.Join(r.commonDir(), "config"))
+	if err != nil {
+		return 20
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), "=")
+		if !ok {
+			continue
+		}
+		if strings.EqualFold(strings.TrimSpace(key), "objectformat") &&
+			strings.EqualFold(strings.TrimSpace(value), "sha256") {
+			return 32
+		}
+	}
+	return 20
+}
+
+// commonDir returns the directory holding shared data (config, refs, objects).
+// For linked worktrees this differs from GitDir.
+func (r *Repo) commonDir() string {
+	data, err := os.ReadFile(filepath.Join(r.GitDir, "commondir"))
+	if err != nil {
+		return r.GitDir
+	}
+	dir := strings.TrimSpace(string(data))
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(r.GitDir, dir)
+	}
+	return filepath.Clean(dir)
+}
@@ -0,0 +1,54 @@
+package gitrepo
+
+import (
+	"path/filepath"
+	"sync"
+)
+
+// TrackedChecker answers whether folders contain files tracked by git.
+// Each repository's index is parsed once and shared, so checking many
+// folders of the same repository stays cheap. Safe for concurrent use.
+type TrackedChecker struct {
+	mu      sync.Mutex
+	indexes map[string]*Index // by git dir; nil when unreadable
+}
+
+func NewTrackedChecker() *TrackedChecker {
+	return &TrackedChecker{indexes: make(map[string]*Index)}
+}
+
+// IsTracked reports whether path has files under it tracked by the
+// enclosing repository. Unreadable or missing repositories count as untracked.
+func (t *TrackedChecker) IsTracked(path string) bool {
+	repo, err := Find(path)
+	if err != nil || repo == nil {
+		return false
+	}
+
+	idx := t.index(repo)
+	if idx == nil {
+		return false
+	}
+
+	rel, err := filepath.Rel(repo.WorkTree, path)
+	if err != nil || rel == "." {
+		return false
+	}
+	return idx.HasTrackedUnder(filepath.ToSlash(rel))
+}
+
+func (t *TrackedChecker) index(repo *Repo) *Index {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if idx, ok := t.indexes[repo.GitDir]; ok {
+		return idx
+	}
+
+	idx, err := repo.ReadIndex()
+	if err != nil {
+		idx = nil
+	}
+	t.indexes[repo.GitDir] = idx
+	return idx
+}
@@ -0,0 +1,249 @@
+// Package goal picks dependency folders to delete to free a given amount
+// of disk space, preferring the folders that have been idle the longest.
+package goal
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/d4rthvadr/node-cleaner/internal/filter"
+	"github.com/d4rthvadr/node-cleaner/pkg/models"
+	"github.com/dustin/go-humanize"
+)
+
+// Disk describes the filesystem holding a path
+type Disk struct {
+	Path  string
+	Dev   uint64
+	Free  int64 // bytes available to unprivileged users
+	Total int64
+}
+
+// Stat reads the free space of the filesystem holding path
+func Stat(path string) (Disk, error) {
+	var fsStat syscall.Statfs_t
+	if err := syscall.Statfs(path, &fsStat); err != nil {
+		return Disk{}, fmt.Errorf("statfs %s: %w", path, err)
+	}
+	var st syscall.Stat_t
+	if err := syscall.Stat(path, &st); err != nil {
+		return Disk{}, fmt.Errorf("stat %s: %w", path, err)
+	}
+
+	bsize := uint64(fsStat.Bsize)
+	return Disk{
+		Path:  path,
+		Dev:   uint64(st.Dev),
+		Free:  int64(uint64(fsStat.Bavail) * bsize),
+		Total: int64(uint64(fsStat.Blocks) * bsize),
+	}, nil
+}
+
+// PercentFree is the share of the disk that is free after reclaiming n bytes
+func (d Disk) PercentFree(reclaimed int64) float64 {
+	if d.Total == 0 {
+		return 0
+	}
+	return float64(d.Free+reclaimed) * 100 / float64(d.Total)
+}
+
+// Goal is how many bytes have to be reclaimed on Disk
+type Goal struct {
+	Disk Disk
+	Need int64
+	// Target describes the goal for display, e.g. "50 GB free"
+	Target string
+}
+
+// Parse builds a goal from --target-free (a size or a percentage of the
+// disk, e.g. 50GB or 20%) or --reclaim (a size). Exactly one may be set.
+func Parse(targetFree, reclaim string, disk Disk) (*Goal, error) {
+	switch {
+	case targetFree != "" && reclaim != "":
+		return nil, fmt.Errorf("--target-free and --reclaim cannot be combined")
+	case reclaim != "":
+		n, err := humanize.ParseBytes(reclaim)
+		if err != nil {
+			return nil, fmt.Errorf("--reclaim: %w", err)
+		}
+		return &Goal{Disk: disk, Need: int64(n), Target: humanize.Bytes(n) + " reclaimed"}, nil
+	case targetFree != "":
+		target, err := parseTarget(targetFree, disk.Total)
+		if err != nil {
+			return nil, fmt.Errorf("--target-free: %w", err)
+		}
+		if target > disk.Total {
+			return nil, fmt.Errorf("--target-free: %s is more than the disk holds (%s)",
+				humanize.Bytes(uint64(target)), humanize.Bytes(uint64(disk.Total)))
+		}
+		need := target - disk.Free
+		if need < 0 {
+			need = 0
+		}
+		return &Goal{Disk: disk, Need: need, Target: humanize.Bytes(uint64(target)) + " free"}, nil
+	}
+	return nil, nil
+}
+
+func parseTarget(value string, total int64) (int64, error) {
+	if pct, ok := strings.CutSuffix(strings.TrimSpace(value), "%"); ok {
+		p, err := strconv.ParseFloat(strings.TrimSpace(pct), 64)
+		if err != nil || p <= 0 || p > 100 {
+			return 0, fmt.Errorf("invalid percentage %q", value)
+		}
+		return int64(float64(total) * p / 100), nil
+	}
+	n, err := humanize.ParseBytes(value)
+	if err != nil {
+		return 0, err
+	}
+	return int64(n), nil
+}
+
+// SizeFunc returns how many bytes deleting a folder frees
+type SizeFunc func(folder models.DependencyFolder) (int64, error)
+
+// Choice is one folder picked to meet the goal
+type Choice struct {
+	Folder      models.DependencyFolder
+	Reclaimable int64
+}
+
+// Selection is the set of folders chosen for a goal
+type Selection struct {
+	Goal        *Goal
+	Chosen      []Choice
+	Reclaimable int64
+}
+
+// Met reports whether deleting the selection reaches the goal
+func (s *Selection) Met() bool {
+	return s.Reclaimable >= s.Goal.Need
+}
+
+// Folders returns the chosen folders
+func (s *Selection) Folders() []models.DependencyFolder {
+	folders := make([]models.DependencyFolder, len(s.Chosen))
+	for i, c := range s.Chosen {
+		folders[i] = c.Folder
+	}
+	return folders
+}
+
+// Update makes the selection exactly folders, such as the chosen folders
+// completed with the rest of their workspaces, so the projection covers
+// everything that will be deleted. Bytes already measured are reused.
+func (s *Selection) Update(folders []models.DependencyFolder, sizeOf SizeFunc) {
+	measured := make(map[string]int64, len(s.Chosen))
+	for _, c := range s.Chosen {
+		measured[c.Folder.Path] = c.Reclaimable
+	}
+
+	s.Chosen, s.Reclaimable = nil, 0
+	for _, f := range folders {
+		n, ok := measured[f.Path]
+		if !ok {
+			var err error
+			if n, err = sizeOf(f); err != nil || n < 0 {
+				n = 0 // deleted all the same, it just frees nothing here
+			}
+		}
+		s.Chosen = append(s.Chosen, Choice{Folder: f, Reclaimable: n})
+		s.Reclaimable += n
+	}
+}
+
+// Select picks folders until their reclaimable bytes meet the goal, taking
+// the longest idle first. Folders that turn out not to be needed once the
+// goal is met are dropped again, newest first, so the set stays small.
+// Protected folders and folders that free nothing are never chosen.
+func (g *Goal) Select(folders []models.DependencyFolder, sizeOf SizeFunc) *Selection {
+	sel := &Selection{Goal: g}
+	if g.Need <= 0 {
+		return sel
+	}
+
+	candidates := make([]models.DependencyFolder, 0, len(folders))
+	for _, f := range folders {
+		if f.Protected == "" {
+			candidates = append(candidates, f)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return filter.LastActivity(candidates[i]).Before(filter.LastActivity(candidates[j]))
+	})
+
+	for _, f := range candidates {
+		if sel.Met() {
+			break
+		}
+		n, err := sizeOf(f)
+		if err != nil || n <= 0 {
+			continue
+		}
+		sel.Chosen = append(sel.Chosen, Choice{Folder: f, Reclaimable: n})
+		sel.Reclaimable += n
+	}
+
+	if !sel.Met() {
+		return sel
+	}
+
+	// the last folder taken may cover the goal on its own, making some of
+	// the newer, smaller ones picked before it unnecessary
+	for i := len(sel.Chosen) - 2; i >= 0; i-- {
+		if sel.Reclaimable-sel.Chosen[i].Reclaimable >= g.Need {
+			sel.Reclaimable -= sel.Chosen[i].Reclaimable
+			sel.Chosen = append(sel.Chosen[:i], sel.Chosen[i+1:]...)
+		}
+	}
+	return sel
+}
+
+// Reclaimable returns the bytes deleting path frees on the device dev:
+// allocated blocks of files whose every hard link is inside path. Files
+// shared with other locations (such as a pnpm store) and anything on
+// another filesystem free nothing there.
+func Reclaimable(path string, dev uint64) (int64, error) {
+	var total int64
+	seen := make(map[uint64]uint64) // inode -> links found inside path
+
+	err := filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil // unreadable entries are simply not counted
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		st, ok := info.Sys().(*syscall.Stat_t)
+		if !ok {
+			return nil
+		}
+		if uint64(st.Dev) != dev {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		blocks := int64(st.Blocks) * 512
+		if d.IsDir() || uint64(st.Nlink) <= 1 {
+			total += blocks
+			return nil
+		}
+
+		ino := uint64(st.Ino)
+		seen[ino]++
+		if seen[ino] == uint64(st.Nlink) {
+			total += blocks
+		}
+		return nil
+	})
+	return total, err
+}
@@ -0,0 +1,167 @@
+package goal
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/d4rthvadr/node-cleaner/pkg/models"
+)
+
+const gb = 1 << 30
+
+func TestParse(t *testing.T) {
+	disk := Disk{Free: 30 * gb, Total: 200 * gb}
+
+	tests := []struct {
+		name       string
+		targetFree string
+		reclaim    string
+		want       int64
+		wantErr    bool
+	}{
+		{"Reclaim", "", "20GiB", 20 * gb, false},
+		{"Target free", "50GiB", "", 20 * gb, false},
+		{"Target percentage", "25%", "", 20 * gb, false},
+		{"Already met", "10GiB", "", 0, false},
+		{"Both", "50GB", "20GB", 0, true},
+		{"Bad percentage", "120%", "", 0, true},
+		{"Larger than disk", "1TB", "", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := Parse(tt.targetFree, tt.reclaim, disk)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Parse() = %+v; want error", g)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if g.Need != tt.want {
+				t.Errorf("Need = %d; want %d", g.Need, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectPrefersStaleFolders(t *testing.T) {
+	now := time.Now()
+	folder := func(name string, monthsIdle int, size int64) models.DependencyFolder {
+		return models.DependencyFolder{Path: "/p/" + name, Size: size, ModTime: now.AddDate(0, -monthsIdle, 0)}
+	}
+	folders := []models.DependencyFolder{
+		folder("recent", 1, 50*gb),
+		folder("old-small", 12, 2*gb),
+		folder("older-big", 9, 30*gb),
+		folder("oldest", 24, 1*gb),
+		folder("locked", 36, 80*gb),
+	}
+	folders[4].Protected = "project protected"
+
+	sizeOf := func(f models.DependencyFolder) (int64, error) { return f.Size, nil }
+
+	tests := []struct {
+		name string
+		need int64
+		want []string
+		met  bool
+	}{
+		{"Oldest first", 3 * gb, []string{"/p/oldest", "/p/old-small"}, true},
+		{"Unneeded folders dropped", 25 * gb, []string{"/p/older-big"}, true},
+		{"Falls short", 100 * gb, []string{"/p/oldest", "/p/old-small", "/p/older-big", "/p/recent"}, false},
+		{"Nothing needed", 0, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sel := (&Goal{Need: tt.need}).Select(folders, sizeOf)
+
+			var got []string
+			for _, f := range sel.Folders() {
+				got = append(got, f.Path)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("chose %v; want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("chose %v; want %v", got, tt.want)
+				}
+			}
+			if sel.Met() != tt.met {
+				t.Errorf("Met() = %v; want %v", sel.Met(), tt.met)
+			}
+		})
+	}
+}
+
+func TestUpdateMeasuresAddedFolders(t *testing.T) {
+	measured := 0
+	sizeOf := func(f models.DependencyFolder) (int64, error) {
+		measured++
+		return f.Size, nil
+	}
+	root := models.DependencyFolder{Path: "/ws/node_modules", Size: 4 * gb}
+	member := models.DependencyFolder{Path: "/ws/packages/ui/node_modules", Size: 1 * gb}
+
+	sel := (&Goal{Need: 5 * gb}).Select([]models.DependencyFolder{root}, sizeOf)
+	if sel.Met() {
+		t.Fatal("Met() = true for the root alone")
+	}
+
+	sel.Update([]models.DependencyFolder{root, member}, sizeOf)
+	if len(sel.Chosen) != 2 || sel.Reclaimable != 5*gb || !sel.Met() {
+		t.Errorf("Update() = %+v; want both folders reclaiming 5GB", sel)
+	}
+	if measured != 2 {
+		t.Errorf("measured %d folders; want the root once and the added member", measured)
+	}
+}
+
+func TestReclaimableSkipsSharedHardLinks(t *testing.T) {
+	root := t.TempDir()
+	store := filepath.Join(root, "store")
+	dir := filepath.Join(root, "node_modules")
+	for _, d := range []string{store, dir} {
+		if err := os.MkdirAll(d, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data := make([]byte, 64<<10)
+	own := filepath.Join(dir, "own.js")
+	shared := filepath.Join(store, "shared.js")
+	for _, f := range []string{own, shared} {
+		if err := os.WriteFile(f, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Link(shared, filepath.Join(dir, "shared.js")); err != nil {
+		t.Skipf("hard links not supported: %v", err)
+	}
+
+	var st syscall.Stat_t
+	if err := syscall.Stat(dir, &st); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Reclaimable(dir, uint64(st.Dev))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var ownStat syscall.Stat_t
+	if err := syscall.Stat(own, &ownStat); err != nil {
+		t.Fatal(err)
+	}
+	// the directory itself plus own.js; shared.js lives on in the store
+	want := int64(st.Blocks)*512 + int64(ownStat.Blocks)*512
+	if got != want {
+		t.Errorf("Reclaimable() = %d; want %d", got, want)
+	}
+}
@@ -0,0 +1,82 @@
+// Package logger builds the structured (log/slog) logger shared by the
+// scanner, analyzer, cache and cleaner.
+package logger
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Logger is the logging contract used across packages; *slog.Logger satisfies it
+type Logger interface {
+	Debug(msg string, args ...any)
+	Info(msg string, args ...any)
+	Warn(msg string, args ...any)
+	Error(msg string, args ...any)
+}
+
+// Options configure where and how log records are written
+type Options struct {
+	Path   string // log file, appended to; empty disables file output
+	Level  string // debug, info, warn or error
+	Format string // text or json
+	Stderr bool   // also write records to stderr
+}
+
+// Discard returns a logger that drops every record
+func Discard() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// New builds a logger from opts. The returned closer releases the log file.
+func New(opts Options) (*slog.Logger, io.Closer, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(opts.Level)); err != nil {
+		return nil, nil, fmt.Errorf("invalid log level %q (want debug, info, warn or error)", opts.Level)
+	}
+
+	var writers []io.Writer
+	var closer io.Closer = nopCloser{}
+
+	if opts.Path != "" {
+		if err := os.MkdirAll(filepath.Dir(opts.Path), 0755); err != nil {
+			return nil, nil, fmt.Errorf("creating log directory: %w", err)
+		}
+		f, err := os.OpenFile(opts.Path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+		if err != nil {
+			return nil, nil, fmt.Errorf("opening log file: %w", err)
+		}
+		writers = append(writers, f)
+		closer = f
+	}
+	if opts.Stderr {
+		writers = append(writers, os.Stderr)
+	}
+	if len(writers) == 0 {
+		return Discard(), closer, nil
+	}
+
+	w := io.MultiWriter(writers...)
+	handlerOpts := &slog.HandlerOptions{Level: level}
+
+	var handler slog.Handler
+	switch strings.ToLower(opts.Format) {
+	case "", "text":
+		handler = slog.NewTextHandler(w, handlerOpts)
+	case "json":
+		handler = slog.NewJSONHandler(w, handlerOpts)
+	default:
+		closer.Close()
+		return nil, nil, fmt.Errorf("invalid log format %q (want text or json)", opts.Format)
+	}
+
+	return slog.New(handler), closer, nil
+}
+
+type nopCloser struct{}
+
+func (nopCloser) Close() error { return nil }
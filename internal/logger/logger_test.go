@@ -0,0 +1,61 @@
+package logger
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewWritesToFile(t *testing.T) {
+	tests := []struct {
+		name   string
+		level  string
+		format string
+		want   []string
+	}{
+		{"text at info drops debug", "info", "text", []string{"msg=kept"}},
+		{"json at debug keeps all", "debug", "json", []string{`"msg":"dropped"`, `"msg":"kept"`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "logs", "depocleaner.log")
+
+			log, closer, err := New(Options{Path: path, Level: tt.level, Format: tt.format})
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
+			log.Debug("dropped")
+			log.Info("kept", "path", "/tmp/x")
+			closer.Close()
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("reading log: %v", err)
+			}
+			lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+			if len(lines) != len(tt.want) {
+				t.Fatalf("got %d lines, want %d:\n%s", len(lines), len(tt.want), data)
+			}
+			for i, want := range tt.want {
+				if !strings.Contains(lines[i], want) {
+					t.Errorf("line %d = %q, want it to contain %q", i, lines[i], want)
+				}
+				if tt.format == "json" && !json.Valid([]byte(lines[i])) {
+					t.Errorf("line %d is not valid JSON: %q", i, lines[i])
+				}
+			}
+		})
+	}
+}
+
+func TestNewRejectsInvalidOptions(t *testing.T) {
+	if _, _, err := New(Options{Level: "loud"}); err == nil {
+		t.Error("expected error for unknown level")
+	}
+	if _, _, err := New(Options{Level: "info", Format: "xml", Stderr: true}); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
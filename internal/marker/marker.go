@@ -0,0 +1,215 @@
+// Package marker reads per-project protection files (.depocleanerignore
+// and .depocleaner.yaml) that projects commit to opt out of cleaning.
+package marker
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/d4rthvadr/node-cleaner/pkg/models"
+	"github.com/d4rthvadr/node-cleaner/pkg/utils"
+	"github.com/dustin/go-humanize"
+	"go.yaml.in/yaml/v3"
+)
+
+const (
+	// IgnoreFile lists protected subpaths, one glob per line; an empty
+	// file protects the whole project
+	IgnoreFile = ".depocleanerignore"
+	// ConfigFile is the YAML form with the same options
+	ConfigFile = ".depocleaner.yaml"
+)
+
+// Marker is the protection a project declares for itself
+type Marker struct {
+	Root   string // project directory holding the file
+	File   string // name of the marker file
+	Whole  bool   // every dependency folder in the project is protected
+	Paths  []string
+	MinAge time.Duration // folders changed more recently are protected
+	Reason string
+	// Err is why the marker file could not be read or parsed. Such a
+	// marker protects the whole project rather than none of it.
+	Err error
+}
+
+// fileConfig is the schema of .depocleaner.yaml
+type fileConfig struct {
+	Protect bool     `yaml:"protect"`
+	Paths   []string `yaml:"paths"`
+	MinAge  string   `yaml:"min_age"`
+	Reason  string   `yaml:"reason"`
+}
+
+// Load reads the marker in dir, returning nil when there is none.
+// .depocleaner.yaml takes precedence over .depocleanerignore.
+func Load(dir string) (*Marker, error) {
+	m, err := loadConfig(dir)
+	if m != nil || err != nil {
+		return m, err
+	}
+	return loadIgnore(dir)
+}
+
+func loadConfig(dir string) (*Marker, error) {
+	data, err := os.ReadFile(filepath.Join(dir, ConfigFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg fileConfig
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", filepath.Join(dir, ConfigFile), err)
+	}
+
+	m := &Marker{Root: dir, File: ConfigFile, Paths: cfg.Paths, Reason: cfg.Reason}
+	if cfg.MinAge != "" {
+		if m.MinAge, err = utils.ParseAge(cfg.MinAge); err != nil {
+			return nil, fmt.Errorf("%s: min_age: %w", filepath.Join(dir, ConfigFile), err)
+		}
+	}
+	m.Whole = cfg.Protect || (len(m.Paths) == 0 && m.MinAge == 0)
+	return m, nil
+}
+
+func loadIgnore(dir string) (*Marker, error) {
+	f, err := os.Open(filepath.Join(dir, IgnoreFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	m := &Marker{Root: dir, File: IgnoreFile}
+
+	lines := bufio.NewScanner(f)
+	for lines.Scan() {
+		line := strings.TrimSpace(lines.Text())
+		switch {
+		case line == "" || strings.HasPrefix(line, "#"):
+		case strings.HasPrefix(line, "min-age:"):
+			age, err := utils.ParseAge(strings.TrimSpace(strings.TrimPrefix(line, "min-age:")))
+			if err != nil {
+				return nil, fmt.Errorf("%s: min-age: %w", filepath.Join(dir, IgnoreFile), err)
+			}
+			m.MinAge = age
+		case line == "*" || line == "/":
+			m.Whole = true
+		default:
+			m.Paths = append(m.Paths, line)
+		}
+	}
+	if err := lines.Err(); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", filepath.Join(dir, IgnoreFile), err)
+	}
+
+	m.Whole = m.Whole || (len(m.Paths) == 0 && m.MinAge == 0)
+	return m, nil
+}
+
+// Protects returns why the marker protects folder, or "" if it doesn't
+func (m *Marker) Protects(folder models.DependencyFolder, now time.Time) string {
+	if !utils.IsWithin(folder.Path, m.Root) {
+		return ""
+	}
+	if m.Err != nil {
+		return "invalid marker: " + m.Err.Error()
+	}
+
+	source := filepath.Join(m.Root, m.File)
+	if m.Reason != "" {
+		source += ": " + m.Reason
+	}
+
+	if m.Whole {
+		return "project protected by " + source
+	}
+
+	rel, err := filepath.Rel(m.Root, folder.Path)
+	if err != nil {
+		return ""
+	}
+	for _, pattern := range m.Paths {
+		// patterns are relative to the project root
+		if utils.MatchGlob("/"+strings.TrimPrefix(pattern, "/"), "/"+filepath.ToSlash(rel)) {
+			return fmt.Sprintf("%s protected by %s", rel, source)
+		}
+	}
+
+	if m.MinAge > 0 && now.Sub(folder.ModTime) < m.MinAge {
+		return fmt.Sprintf("changed %s, %s requires %s", humanize.Time(folder.ModTime), source, formatAge(m.MinAge))
+	}
+	return ""
+}
+
+func formatAge(d time.Duration) string {
+	if d%(24*time.Hour) == 0 {
+		return fmt.Sprintf("%dd", d/(24*time.Hour))
+	}
+	return d.String()
+}
+
+// Finder looks up the markers that apply to a folder, remembering every
+// directory it has already checked
+type Finder struct {
+	mu    sync.Mutex
+	dirs  map[string]*Marker
+	onErr func(err error)
+}
+
+// NewFinder returns an empty Finder. onErr, if set, receives errors from
+// unreadable or invalid marker files; their projects count as protected.
+func NewFinder(onErr func(err error)) *Finder {
+	return &Finder{dirs: make(map[string]*Marker), onErr: onErr}
+}
+
+// Protects checks the markers in every directory from the folder's parent
+// up to the filesystem root and returns the first reason found. Relative
+// folder paths are resolved against the working directory first.
+func (f *Finder) Protects(folder models.DependencyFolder, now time.Time) string {
+	if abs, err := filepath.Abs(folder.Path); err == nil {
+		folder.Path = abs
+	}
+	for dir := filepath.Dir(folder.Path); ; dir = filepath.Dir(dir) {
+		if m := f.markerIn(dir); m != nil {
+			if reason := m.Protects(folder, now); reason != "" {
+				return reason
+			}
+		}
+		if parent := filepath.Dir(dir); parent == dir {
+			return ""
+		}
+	}
+}
+
+func (f *Finder) markerIn(dir string) *Marker {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if m, ok := f.dirs[dir]; ok {
+		return m
+	}
+
+	m, err := Load(dir)
+	if err != nil {
+		if f.onErr != nil {
+			f.onErr(err)
+		}
+		// a marker that can't be read may well be meant to protect
+		m = &Marker{Root: dir, Whole: true, Err: err}
+	}
+	f.dirs[dir] = m
+	return m
+}
@@ -0,0 +1,121 @@
+package marker
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/d4rthvadr/node-cleaner/pkg/models"
+)
+
+func TestProtects(t *testing.T) {
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	old := now.AddDate(-1, 0, 0)
+
+	tests := []struct {
+		name   string
+		file   string
+		body   string
+		folder string
+		mtime  time.Time
+		want   string // substring of the reason, "" for unprotected
+	}{
+		{"Empty ignore file protects everything", IgnoreFile, "", "node_modules", old, "project protected by"},
+		{"Ignore file subpath", IgnoreFile, "# legacy\npackages/legacy/**\n", "packages/legacy/node_modules", old, "packages/legacy/node_modules protected by"},
+		{"Ignore file other subpath", IgnoreFile, "packages/legacy/**\n", "packages/web/node_modules", old, ""},
+		{"Ignore file min age", IgnoreFile, "min-age: 30d\n", "node_modules", now.AddDate(0, 0, -3), "requires 30d"},
+		{"Ignore file min age passed", IgnoreFile, "min-age: 30d\n", "node_modules", old, ""},
+		{"YAML whole project with reason", ConfigFile, "protect: true\nreason: client handover\n", "target", old, "client handover"},
+		{"YAML paths", ConfigFile, "paths:\n  - tools/vendor\n", "tools/vendor", old, "tools/vendor protected by"},
+		{"YAML min age only", ConfigFile, "min_age: 2w\n", "vendor", old, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			if err := os.WriteFile(filepath.Join(root, tt.file), []byte(tt.body), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			folder := models.DependencyFolder{Path: filepath.Join(root, tt.folder), ModTime: tt.mtime}
+			got := NewFinder(nil).Protects(folder, now)
+
+			if tt.want == "" && got != "" {
+				t.Errorf("Protects() = %q; want unprotected", got)
+			}
+			if tt.want != "" && !strings.Contains(got, tt.want) {
+				t.Errorf("Protects() = %q; want it to contain %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFinderChecksAncestors(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, IgnoreFile), []byte("apps/*/node_modules\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	f := NewFinder(nil)
+	protected := models.DependencyFolder{Path: filepath.Join(root, "apps", "web", "node_modules")}
+	other := models.DependencyFolder{Path: filepath.Join(root, "libs", "ui", "node_modules")}
+
+	if f.Protects(protected, time.Now()) == "" {
+		t.Error("folder below a marked project is not protected")
+	}
+	if reason := f.Protects(other, time.Now()); reason != "" {
+		t.Errorf("unmatched folder protected: %q", reason)
+	}
+}
+
+func TestFinderProtectsInvalidMarkers(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, ConfigFile), []byte("paths: [unclosed\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var errs []error
+	f := NewFinder(func(err error) { errs = append(errs, err) })
+	folder := models.DependencyFolder{Path: filepath.Join(root, "node_modules")}
+
+	reason := f.Protects(folder, time.Now())
+	if !strings.HasPrefix(reason, "invalid marker: ") {
+		t.Errorf("Protects() = %q; want the folder protected by the invalid marker", reason)
+	}
+	if len(errs) != 1 {
+		t.Errorf("onErr called %d times; want 1", len(errs))
+	}
+}
+
+func TestFinderResolvesRelativePaths(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, IgnoreFile), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	app := filepath.Join(root, "app")
+	if err := os.Mkdir(app, 0755); err != nil {
+		t.Fatal(err)
+	}
+	chdir(t, app)
+
+	// the marker is above the working directory
+	folder := models.DependencyFolder{Path: "node_modules"}
+	if NewFinder(nil).Protects(folder, time.Now()) == "" {
+		t.Error("relative folder below a marked project is not protected")
+	}
+}
+
+// chdir changes the working directory for the rest of the test
+func chdir(t *testing.T, dir string) {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+}
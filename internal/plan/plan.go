@@ -0,0 +1,192 @@
+// Package plan writes selected folders to a reviewable file that can be
+// applied later, refusing folders that changed since they were planned.
+package plan
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/d4rthvadr/node-cleaner/internal/analyzer"
+	"github.com/d4rthvadr/node-cleaner/pkg/models"
+	"github.com/d4rthvadr/node-cleaner/pkg/utils"
+	"github.com/dustin/go-humanize"
+)
+
+// Version is the plan file format written by this build
+const Version = 1
+
+// ErrDrifted is returned when a folder no longer matches its plan entry
+var ErrDrifted = errors.New("changed since planning")
+
+// Entry is one folder scheduled for deletion
+type Entry struct {
+	Path      string    `json:"path"`
+	Detector  string    `json:"detector"` // folder name that marked it as a dependency folder
+	Ecosystem string    `json:"ecosystem"`
+	Size      int64     `json:"size"`
+	ModTime   time.Time `json:"mtime"`
+	// Fingerprint hashes the folder's top-level entries so added or
+	// removed packages are noticed without walking the whole tree
+	Fingerprint string `json:"fingerprint"`
+	Tracked     bool   `json:"tracked,omitempty"`
+}
+
+// Plan is the reviewable file written by clean --plan
+type Plan struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	CreatedBy string    `json:"created_by,omitempty"`
+	Host      string    `json:"host,omitempty"`
+	Root      string    `json:"root"` // path that was scanned
+	Entries   []Entry   `json:"entries"`
+}
+
+// New builds a plan for folders found under root. Paths are stored
+// absolute so the plan can be applied from any working directory. Sizes
+// and mtimes are measured again with a rather than taken from the scan,
+// which may have served them from a stale cache, so Verify agrees with
+// the plan until the folder really changes.
+func New(a *analyzer.Analyzer, root string, folders []models.DependencyFolder) (*Plan, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	p := &Plan{
+		Version:   Version,
+		CreatedAt: time.Now(),
+		Root:      root,
+	}
+	if u, err := user.Current(); err == nil {
+		p.CreatedBy = u.Username
+	}
+	p.Host, _ = os.Hostname()
+
+	for _, f := range folders {
+		path, err := filepath.Abs(f.Path)
+		if err != nil {
+			return nil, err
+		}
+		info, err := os.Lstat(path)
+		if err != nil {
+			return nil, err
+		}
+		fingerprint, err := Fingerprint(path)
+		if err != nil {
+			return nil, fmt.Errorf("fingerprinting %s: %w", path, err)
+		}
+		measured, err := a.Analyze(path)
+		if err != nil {
+			return nil, fmt.Errorf("measuring %s: %w", path, err)
+		}
+		p.Entries = append(p.Entries, Entry{
+			Path:        path,
+			Detector:    filepath.Base(f.Path),
+			Ecosystem:   f.Type,
+			Size:        measured.Size,
+			ModTime:     info.ModTime(),
+			Fingerprint: fingerprint,
+			Tracked:     f.Tracked,
+		})
+	}
+	return p, nil
+}
+
+// TotalSize is the space the plan would reclaim
+func (p *Plan) TotalSize() int64 {
+	var total int64
+	for _, e := range p.Entries {
+		total += e.Size
+	}
+	return total
+}
+
+// Write saves the plan as indented JSON so it diffs well in review
+func Write(path string, p *Plan) error {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// Read loads a plan written by Write
+func Read(path string) (*Plan, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Plan{}
+	if err := json.Unmarshal(data, p); err != nil {
+		return nil, fmt.Errorf("reading plan: %w", err)
+	}
+	if p.Version != Version {
+		return nil, fmt.Errorf("unsupported plan version %d (want %d)", p.Version, Version)
+	}
+	return p, nil
+}
+
+// Fingerprint hashes the names, types, sizes and modification times of
+// the entries directly inside dir
+func Fingerprint(dir string) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+
+	hash := sha256.New()
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			continue // removed while listing, the mtime check catches it
+		}
+		fmt.Fprintf(hash, "%s\x00%v\x00%d\x00%d\n", entry.Name(), info.Mode().Type(), info.Size(), info.ModTime().UnixNano())
+	}
+	return "sha256:" + hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// Verify checks that the folder still matches the entry and returns its
+// current state. Drift is reported as an error wrapping ErrDrifted.
+func Verify(a *analyzer.Analyzer, e Entry) (*models.DependencyFolder, error) {
+	info, err := os.Lstat(e.Path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%w: no longer a directory", ErrDrifted)
+	}
+	if filepath.Base(e.Path) != e.Detector || !utils.IsTargetDirectory(e.Detector) {
+		return nil, fmt.Errorf("%w: not detected as %s", ErrDrifted, e.Detector)
+	}
+	if !info.ModTime().Equal(e.ModTime) {
+		return nil, fmt.Errorf("%w: mtime %s, planned %s", ErrDrifted,
+			info.ModTime().Format(time.RFC3339Nano), e.ModTime.Format(time.RFC3339Nano))
+	}
+
+	fingerprint, err := Fingerprint(e.Path)
+	if err != nil {
+		return nil, err
+	}
+	if fingerprint != e.Fingerprint {
+		return nil, fmt.Errorf("%w: contents differ", ErrDrifted)
+	}
+
+	folder, err := a.Analyze(e.Path)
+	if err != nil {
+		return nil, err
+	}
+	if folder.Size != e.Size {
+		return nil, fmt.Errorf("%w: size %s, planned %s", ErrDrifted,
+			humanize.Bytes(uint64(folder.Size)), humanize.Bytes(uint64(e.Size)))
+	}
+	return folder, nil
+}
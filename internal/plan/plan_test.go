@@ -0,0 +1,152 @@
+package plan
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/d4rthvadr/node-cleaner/internal/analyzer"
+	"github.com/d4rthvadr/node-cleaner/pkg/models"
+)
+
+func newFolder(t *testing.T) models.DependencyFolder {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "app", "node_modules")
+	if err := os.MkdirAll(filepath.Join(path, "lodash"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(path, "lodash", "index.js"), []byte("module.exports = {}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	folder, err := analyzer.NewAnalyzer().Analyze(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return *folder
+}
+
+func TestWriteReadVerify(t *testing.T) {
+	folder := newFolder(t)
+
+	p, err := New(analyzer.NewAnalyzer(), filepath.Dir(filepath.Dir(folder.Path)), []models.DependencyFolder{folder})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "plan.json")
+	if err := Write(path, p); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	loaded, err := Read(path)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if len(loaded.Entries) != 1 || loaded.Entries[0].Detector != "node_modules" || loaded.TotalSize() != folder.Size {
+		t.Fatalf("loaded plan = %+v", loaded)
+	}
+
+	if _, err := Verify(analyzer.NewAnalyzer(), loaded.Entries[0]); err != nil {
+		t.Errorf("Verify() of an unchanged folder error = %v", err)
+	}
+}
+
+func TestVerifyDetectsDrift(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(t *testing.T, path string)
+	}{
+		{"Package added", func(t *testing.T, path string) {
+			if err := os.Mkdir(filepath.Join(path, "react"), 0755); err != nil {
+				t.Fatal(err)
+			}
+		}},
+		{"File grew", func(t *testing.T, path string) {
+			if err := os.WriteFile(filepath.Join(path, "lodash", "index.js"), []byte("module.exports = { a: 1 }"), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}},
+		{"Mtime changed", func(t *testing.T, path string) {
+			later := time.Now().Add(time.Hour)
+			if err := os.Chtimes(path, later, later); err != nil {
+				t.Fatal(err)
+			}
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			folder := newFolder(t)
+			p, err := New(analyzer.NewAnalyzer(), filepath.Dir(folder.Path), []models.DependencyFolder{folder})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			tt.change(t, folder.Path)
+
+			if _, err := Verify(analyzer.NewAnalyzer(), p.Entries[0]); !errors.Is(err, ErrDrifted) {
+				t.Errorf("Verify() error = %v; want ErrDrifted", err)
+			}
+		})
+	}
+}
+
+func TestRelativePlanAppliesFromAnotherDirectory(t *testing.T) {
+	folder := newFolder(t)
+	app := filepath.Dir(folder.Path)
+	abs := folder.Path
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	// plan "clean . --plan" from inside the project
+	if err := os.Chdir(app); err != nil {
+		t.Fatal(err)
+	}
+	folder.Path = "node_modules"
+	p, err := New(analyzer.NewAnalyzer(), ".", []models.DependencyFolder{folder})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "plan.json")
+	if err := Write(path, p); err != nil {
+		t.Fatal(err)
+	}
+
+	// and apply it from somewhere else
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := Read(path)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if loaded.Root != app || loaded.Entries[0].Path != abs {
+		t.Errorf("plan root %s, entry %s; want %s and %s", loaded.Root, loaded.Entries[0].Path, app, abs)
+	}
+	if _, err := Verify(analyzer.NewAnalyzer(), loaded.Entries[0]); err != nil {
+		t.Errorf("Verify() from another directory error = %v", err)
+	}
+}
+
+func TestNewMeasuresStaleSizes(t *testing.T) {
+	folder := newFolder(t)
+	size := folder.Size
+	folder.Size = 1 // as served by an outdated cache entry
+
+	p, err := New(analyzer.NewAnalyzer(), filepath.Dir(folder.Path), []models.DependencyFolder{folder})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if p.Entries[0].Size != size {
+		t.Errorf("planned size = %d; want the measured %d", p.Entries[0].Size, size)
+	}
+	if _, err := Verify(analyzer.NewAnalyzer(), p.Entries[0]); err != nil {
+		t.Errorf("Verify() right after planning error = %v", err)
+	}
+}
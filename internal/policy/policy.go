@@ -0,0 +1,153 @@
+// Package policy evaluates the ordered cleanup rules from the policies
+// section of the config against scanned folders.
+package policy
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/d4rthvadr/node-cleaner/internal/filter"
+	"github.com/d4rthvadr/node-cleaner/pkg/models"
+	"github.com/d4rthvadr/node-cleaner/pkg/utils"
+)
+
+// Action is what a rule does with the folders it matches
+type Action string
+
+const (
+	// ActionNone means no rule matched; the folder is left alone
+	ActionNone      Action = ""
+	ActionProtect   Action = "protect"
+	ActionSuggest   Action = "suggest"
+	ActionAutoClean Action = "auto-clean"
+	ActionTrash     Action = "trash"
+)
+
+// rule is a PolicyRule with its match part parsed
+type rule struct {
+	name    string
+	action  Action
+	filter  *filter.Filter
+	tracked *bool
+	inSync  *bool
+}
+
+// Policy is a compiled, ordered list of rules
+type Policy struct {
+	rules []rule
+}
+
+// Decision is the outcome of evaluating a folder
+type Decision struct {
+	Rule   string // name of the matching rule, empty when none matched
+	Action Action
+}
+
+// Compile validates the configured rules
+func Compile(rules []models.PolicyRule) (*Policy, error) {
+	p := &Policy{}
+
+	for i, r := range rules {
+		name := r.Name
+		if name == "" {
+			name = fmt.Sprintf("rule %d", i+1)
+		}
+
+		action := Action(strings.ToLower(r.Action))
+		switch action {
+		case ActionProtect, ActionSuggest, ActionAutoClean, ActionTrash:
+		default:
+			return nil, fmt.Errorf("policy %s: unknown action %q (want protect, suggest, auto-clean or trash)", name, r.Action)
+		}
+
+		opts := filter.Options{
+			OlderThan: r.Match.OlderThan,
+			MinSize:   r.Match.MinSize,
+		}
+		if r.Match.Ecosystem != "" {
+			opts.Types = strings.Split(r.Match.Ecosystem, ",")
+		}
+		if r.Match.Path != "" {
+			opts.PathGlobs = []string{r.Match.Path}
+		}
+		f, err := filter.Parse(opts)
+		if err != nil {
+			return nil, fmt.Errorf("policy %s: %w", name, err)
+		}
+
+		p.rules = append(p.rules, rule{
+			name:    name,
+			action:  action,
+			filter:  f,
+			tracked: r.Match.Tracked,
+			inSync:  r.Match.InSync,
+		})
+	}
+
+	return p, nil
+}
+
+// IsEmpty reports whether no rules are configured
+func (p *Policy) IsEmpty() bool {
+	return len(p.rules) == 0
+}
+
+// Evaluate returns the decision of the first rule matching folder.
+// Folders protected by a project marker are always protected.
+func (p *Policy) Evaluate(folder models.DependencyFolder, now time.Time) Decision {
+	if folder.Protected != "" {
+		return Decision{Rule: folder.Protected, Action: ActionProtect}
+	}
+	for _, r := range p.rules {
+		if !r.filter.Match(folder, now) {
+			continue
+		}
+		if r.tracked != nil && folder.Tracked != *r.tracked {
+			continue
+		}
+		if r.inSync != nil && InSync(folder.Path) != *r.inSync {
+			continue
+		}
+		return Decision{Rule: r.name, Action: r.action}
+	}
+	return Decision{Action: ActionNone}
+}
+
+// Protects returns why folder must not be cleaned: its marker, or the
+// protect rule it matches first. It returns "" for every other decision.
+func (p *Policy) Protects(folder models.DependencyFolder, now time.Time) string {
+	d := p.Evaluate(folder, now)
+	switch {
+	case d.Action != ActionProtect:
+		return ""
+	case folder.Protected != "":
+		return folder.Protected
+	}
+	return "protected by policy " + d.Rule
+}
+
+// InSync reports whether the folder at path was modified after every
+// lockfile next to it. Folders without a lockfile are never in sync,
+// since nothing says what they should contain.
+func InSync(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+
+	found := false
+	for _, name := range utils.Lockfiles {
+		lock, err := os.Stat(filepath.Join(filepath.Dir(path), name))
+		if err != nil {
+			continue
+		}
+		found = true
+		if lock.ModTime().After(info.ModTime()) {
+			return false
+		}
+	}
+	return found
+}
@@ -0,0 +1,118 @@
+package policy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/d4rthvadr/node-cleaner/pkg/models"
+)
+
+func boolPtr(b bool) *bool { return &b }
+
+func TestEvaluateFirstMatchWins(t *testing.T) {
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	p, err := Compile([]models.PolicyRule{
+		{Name: "keep-work", Match: models.PolicyMatch{Path: "work/**"}, Action: "protect"},
+		{Name: "vendored", Match: models.PolicyMatch{Tracked: boolPtr(true)}, Action: "protect"},
+		{Name: "old-node", Match: models.PolicyMatch{Ecosystem: "node", OlderThan: "90d"}, Action: "auto-clean"},
+		{Name: "big", Match: models.PolicyMatch{MinSize: "1GB"}, Action: "trash"},
+		{Match: models.PolicyMatch{}, Action: "suggest"},
+	})
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+
+	old := now.AddDate(0, -6, 0)
+
+	tests := []struct {
+		name   string
+		folder models.DependencyFolder
+		want   Decision
+	}{
+		{"Protected path", models.DependencyFolder{Path: "/home/dev/work/api/node_modules", Type: "Node.js", ModTime: old},
+			Decision{Rule: "keep-work", Action: ActionProtect}},
+		{"Tracked vendor", models.DependencyFolder{Path: "/src/app/vendor", Type: "Go/PHP", Tracked: true},
+			Decision{Rule: "vendored", Action: ActionProtect}},
+		{"Old node", models.DependencyFolder{Path: "/src/site/node_modules", Type: "Node.js", ModTime: old},
+			Decision{Rule: "old-node", Action: ActionAutoClean}},
+		{"Big rust", models.DependencyFolder{Path: "/src/cli/target", Type: "Rust", Size: 2 << 30, ModTime: now},
+			Decision{Rule: "big", Action: ActionTrash}},
+		{"Catch-all gets a default name", models.DependencyFolder{Path: "/src/py/.venv", Type: "Python", ModTime: now},
+			Decision{Rule: "rule 5", Action: ActionSuggest}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := p.Evaluate(tt.folder, now); got != tt.want {
+				t.Errorf("Evaluate() = %+v; want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProtects(t *testing.T) {
+	p, err := Compile([]models.PolicyRule{
+		{Name: "old", Match: models.PolicyMatch{Path: "old/**"}, Action: "auto-clean"},
+		{Name: "keep-work", Match: models.PolicyMatch{Path: "**/work/**"}, Action: "protect"},
+	})
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		folder models.DependencyFolder
+		want   string
+	}{
+		{"Protect rule", models.DependencyFolder{Path: "/home/dev/work/api/node_modules"}, "protected by policy keep-work"},
+		{"Earlier rule wins", models.DependencyFolder{Path: "/old/work/node_modules"}, ""},
+		{"Marker", models.DependencyFolder{Path: "/src/node_modules", Protected: "pinned"}, "pinned"},
+		{"No match", models.DependencyFolder{Path: "/src/node_modules"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := p.Protects(tt.folder, time.Now()); got != tt.want {
+				t.Errorf("Protects() = %q; want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompileRejectsUnknownAction(t *testing.T) {
+	_, err := Compile([]models.PolicyRule{{Name: "oops", Action: "shred"}})
+	if err == nil {
+		t.Error("Compile() accepted an unknown action")
+	}
+}
+
+func TestInSync(t *testing.T) {
+	project := t.TempDir()
+	modules := filepath.Join(project, "node_modules")
+	if err := os.Mkdir(modules, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if InSync(modules) {
+		t.Error("InSync() = true without a lockfile")
+	}
+
+	lock := filepath.Join(project, "package-lock.json")
+	if err := os.WriteFile(lock, []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	installed := time.Now()
+	os.Chtimes(lock, installed.Add(-time.Hour), installed.Add(-time.Hour))
+	os.Chtimes(modules, installed, installed)
+	if !InSync(modules) {
+		t.Error("InSync() = false for a folder installed after the lockfile")
+	}
+
+	os.Chtimes(lock, installed.Add(time.Hour), installed.Add(time.Hour))
+	if InSync(modules) {
+		t.Error("InSync() = true after the lockfile changed")
+	}
+}
@@ -0,0 +1,31 @@
+// Package procscan finds running processes that reference files inside
+// given folders, so folders in use by a dev server or watcher aren't deleted.
+package procscan
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrUnsupported is returned on platforms without a process scanner
+var ErrUnsupported = errors.New("process scan not supported on this platform")
+
+// Process identifies a running process
+type Process struct {
+	PID     int
+	Command string
+}
+
+func (p Process) String() string {
+	return fmt.Sprintf("%s (pid %d)", p.Command, p.PID)
+}
+
+// Describe formats processes for user facing messages, e.g. "node (pid 4242), tsc (pid 4250)"
+func Describe(procs []Process) string {
+	parts := make([]string, len(procs))
+	for i, p := range procs {
+		parts[i] = p.String()
+	}
+	return strings.Join(parts, ", ")
+}
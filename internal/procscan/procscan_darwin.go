@@ -0,0 +1,8 @@
+//go:build darwin
+
+package procscan
+
+// FindUsers is not implemented on macOS, which has no /proc
+func FindUsers(paths []string) (map[string][]Process, error) {
+	return nil, ErrUnsupported
+}
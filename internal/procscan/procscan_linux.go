@@ -0,0 +1,131 @@
+//go:build linux
+
+package procscan
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/d4rthvadr/node-cleaner/pkg/utils"
+)
+
+const procRoot = "/proc"
+
+// FindUsers scans /proc once and returns, for each folder in paths, the
+// processes whose working directory, executable, open files or memory
+// mappings lie inside it. Processes we may not inspect are skipped.
+// Relative and symlinked paths are resolved before comparing, since
+// /proc only reports absolute, resolved paths; results keep the paths
+// as given.
+func FindUsers(paths []string) (map[string][]Process, error) {
+	entries, err := os.ReadDir(procRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	resolved := make([]string, len(paths))
+	for i, path := range paths {
+		resolved[i] = resolve(path)
+	}
+
+	self := os.Getpid()
+	users := make(map[string][]Process)
+
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil || pid == self {
+			continue
+		}
+
+		refs := processRefs(filepath.Join(procRoot, entry.Name()))
+		if len(refs) == 0 {
+			continue
+		}
+
+		var proc *Process
+		for i, path := range paths {
+			if !referencesPath(refs, resolved[i]) {
+				continue
+			}
+			if proc == nil {
+				proc = &Process{PID: pid, Command: command(pid)}
+			}
+			users[path] = append(users[path], *proc)
+		}
+	}
+
+	return users, nil
+}
+
+// resolve returns path as /proc would show it: absolute, with symlinks
+// resolved where it still exists
+func resolve(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	if real, err := filepath.EvalSymlinks(path); err == nil {
+		path = real
+	}
+	return path
+}
+
+func referencesPath(refs map[string]struct{}, path string) bool {
+	for ref := range refs {
+		if utils.IsWithin(ref, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// processRefs collects every filesystem path a process holds on to
+func processRefs(procDir string) map[string]struct{} {
+	refs := make(map[string]struct{})
+
+	add := func(target string) {
+		target = strings.TrimSuffix(target, " (deleted)")
+		if strings.HasPrefix(target, "/") {
+			refs[target] = struct{}{}
+		}
+	}
+
+	for _, link := range []string{"cwd", "exe"} {
+		if target, err := os.Readlink(filepath.Join(procDir, link)); err == nil {
+			add(target)
+		}
+	}
+
+	fdDir := filepath.Join(procDir, "fd")
+	if fds, err := os.ReadDir(fdDir); err == nil {
+		for _, fd := range fds {
+			if target, err := os.Readlink(filepath.Join(fdDir, fd.Name())); err == nil {
+				add(target)
+			}
+		}
+	}
+
+	if f, err := os.Open(filepath.Join(procDir, "maps")); err == nil {
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			// address perms offset dev inode pathname
+			fields := strings.SplitN(scanner.Text(), " ", 6)
+			if len(fields) == 6 {
+				add(strings.TrimSpace(fields[5]))
+			}
+		}
+		f.Close()
+	}
+
+	return refs
+}
+
+func command(pid int) string {
+	data, err := os.ReadFile(filepath.Join(procRoot, strconv.Itoa(pid), "comm"))
+	if err != nil {
+		return "unknown"
+	}
+	return strings.TrimSpace(string(data))
+}
@@ -0,0 +1,75 @@
+//go:build linux
+
+package procscan
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFindUsersByWorkingDirectory(t *testing.T) {
+	busy := filepath.Join(t.TempDir(), "app", "node_modules")
+	idle := filepath.Join(t.TempDir(), "other", "node_modules")
+	for _, dir := range []string{busy, idle} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cmd := exec.Command("sleep", "30")
+	cmd.Dir = busy
+	if err := cmd.Start(); err != nil {
+		t.Skipf("cannot start helper process: %v", err)
+	}
+	defer cmd.Process.Kill()
+
+	// wait for the child to exec so /proc shows its final cwd and comm
+	var users map[string][]Process
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		var err error
+		users, err = FindUsers([]string{busy, idle})
+		if err != nil {
+			t.Fatalf("FindUsers() error = %v", err)
+		}
+		if len(users[busy]) > 0 && users[busy][0].Command == "sleep" {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	procs := users[busy]
+	if len(procs) != 1 || procs[0].PID != cmd.Process.Pid || procs[0].Command != "sleep" {
+		t.Errorf("users of busy folder = %v; want sleep (pid %d)", procs, cmd.Process.Pid)
+	}
+	if len(users[idle]) != 0 {
+		t.Errorf("users of idle folder = %v; want none", users[idle])
+	}
+
+	// relative and symlinked paths name the same folder
+	link := filepath.Join(t.TempDir(), "link")
+	if err := os.Symlink(busy, link); err != nil {
+		t.Fatal(err)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(filepath.Dir(busy)); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	users, err = FindUsers([]string{"node_modules", link})
+	if err != nil {
+		t.Fatalf("FindUsers() error = %v", err)
+	}
+	for _, path := range []string{"node_modules", link} {
+		if len(users[path]) != 1 {
+			t.Errorf("users of %s = %v; want sleep (pid %d)", path, users[path], cmd.Process.Pid)
+		}
+	}
+}
@@ -0,0 +1,341 @@
+// Package project finds the project a dependency folder belongs to by
+// walking up to the nearest manifest, and reads what the manifest and
+// lockfile say about it.
+package project
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/d4rthvadr/node-cleaner/pkg/models"
+	"github.com/pelletier/go-toml/v2"
+)
+
+// manifest is a file that marks a project root
+type manifest struct {
+	name      string
+	ecosystem string // matches the utils ecosystem aliases
+	folder    string // folder type (as from DetectType) it installs into
+}
+
+// manifests are checked in this order when a directory has several
+var manifests = []manifest{
+	{"package.json", "node", "Node.js"},
+	{"pyproject.toml", "python", "Python"},
+	{"Pipfile", "python", "Python"},
+	{"requirements.txt", "python", "Python"},
+	{"setup.py", "python", "Python"},
+	{"Cargo.toml", "rust", "Rust"},
+	{"composer.json", "php", "Go/PHP"},
+	{"go.mod", "go", "Go/PHP"},
+}
+
+// lockfiles maps each lockfile to the package manager that writes it,
+// in order of preference when a project has several
+var lockfiles = []struct {
+	name    string
+	manager string
+}{
+	{"pnpm-lock.yaml", "pnpm"},
+	{"yarn.lock", "yarn"},
+	{"bun.lock", "bun"},
+	{"bun.lockb", "bun"},
+	{"package-lock.json", "npm"},
+	{"npm-shrinkwrap.json", "npm"},
+	{"uv.lock", "uv"},
+	{"poetry.lock", "poetry"},
+	{"Pipfile.lock", "pipenv"},
+	{"Cargo.lock", "cargo"},
+	{"composer.lock", "composer"},
+	{"go.sum", "go"},
+}
+
+// defaultManagers is the package manager assumed without a lockfile
+var defaultManagers = map[string]string{
+	"package.json":     "npm",
+	"pyproject.toml":   "pip",
+	"Pipfile":          "pipenv",
+	"requirements.txt": "pip",
+	"setup.py":         "pip",
+	"Cargo.toml":       "cargo",
+	"composer.json":    "composer",
+	"go.mod":           "go",
+}
+
+// Load reads the project whose manifest is in dir, or returns nil when
+// dir has none. folderType picks between manifests when there are
+// several, so a .venv next to package.json and pyproject.toml belongs
+// to the Python project.
+func Load(dir, folderType string) *models.Project {
+	var found *manifest
+	for i, m := range manifests {
+		if _, err := os.Stat(filepath.Join(dir, m.name)); err != nil {
+			continue
+		}
+		if found == nil || (m.folder == folderType && found.folder != folderType) {
+			found = &manifests[i]
+		}
+	}
+	if found == nil {
+		return nil
+	}
+
+	p := &models.Project{
+		Root:      dir,
+		Manifest:  found.name,
+		Ecosystem: found.ecosystem,
+	}
+	declared := readManifest(p)
+	if p.Name == "" {
+		p.Name = filepath.Base(dir)
+	}
+
+	for _, l := range lockfiles {
+		if managerEcosystem(l.manager) != found.ecosystem || (declared != "" && l.manager != declared) {
+			continue
+		}
+		if hash, err := hashFile(filepath.Join(dir, l.name)); err == nil {
+			p.PackageManager, p.Lockfile, p.LockfileHash = l.manager, l.name, hash
+			break
+		}
+	}
+	switch {
+	case p.PackageManager != "":
+	case declared != "":
+		p.PackageManager = declared
+	case found.name == "package.json" && pnpmWorkspace(dir):
+		p.PackageManager = "pnpm"
+	default:
+		p.PackageManager = defaultManagers[found.name]
+	}
+	return p
+}
+
+// pnpmWorkspace reports whether dir has a pnpm-workspace.yaml, which only
+// pnpm reads, so it names the package manager even without a lockfile
+func pnpmWorkspace(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, "pnpm-workspace.yaml"))
+	return err == nil
+}
+
+// managerEcosystem returns the ecosystem a package manager serves
+func managerEcosystem(manager string) string {
+	switch manager {
+	case "npm", "yarn", "pnpm", "bun":
+		return "node"
+	case "uv", "poetry", "pipenv", "pip":
+		return "python"
+	case "cargo":
+		return "rust"
+	case "composer":
+		return "php"
+	}
+	return manager
+}
+
+// readManifest fills in the name and version, and returns the package
+// manager the manifest declares, if any
+func readManifest(p *models.Project) (declared string) {
+	data, err := os.ReadFile(filepath.Join(p.Root, p.Manifest))
+	if err != nil {
+		return ""
+	}
+
+	switch p.Manifest {
+	case "package.json", "composer.json":
+		var m struct {
+			Name           string `json:"name"`
+			Version        string `json:"version"`
+			PackageManager string `json:"packageManager"` // e.g. pnpm@9.1.0
+		}
+		if json.Unmarshal(data, &m) == nil {
+			p.Name, p.Version = m.Name, m.Version
+			declared, _, _ = strings.Cut(m.PackageManager, "@")
+		}
+	case "pyproject.toml":
+		var m struct {
+			Project struct {
+				Name    string `toml:"name"`
+				Version string `toml:"version"`
+			} `toml:"project"`
+			Tool struct {
+				Poetry *struct {
+					Name    string `toml:"name"`
+					Version string `toml:"version"`
+				} `toml:"poetry"`
+			} `toml:"tool"`
+		}
+		if toml.Unmarshal(data, &m) == nil {
+			p.Name, p.Version = m.Project.Name, m.Project.Version
+			if poetry := m.Tool.Poetry; poetry != nil {
+				declared = "poetry"
+				if p.Name == "" {
+					p.Name, p.Version = poetry.Name, poetry.Version
+				}
+			}
+		}
+	case "Cargo.toml":
+		var m struct {
+			Package struct {
+				Name    string `toml:"name"`
+				Version any    `toml:"version"` // a string, or { workspace = true }
+			} `toml:"package"`
+		}
+		if toml.Unmarshal(data, &m) == nil {
+			p.Name = m.Package.Name
+			p.Version, _ = m.Package.Version.(string)
+		}
+	case "go.mod":
+		for _, line := range strings.Split(string(data), "\n") {
+			if module, ok := strings.CutPrefix(strings.TrimSpace(line), "module "); ok {
+				p.Name = strings.Trim(strings.TrimSpace(module), `"`)
+				break
+			}
+		}
+	}
+	return declared
+}
+
+// hashFile returns the hex sha256 of the file at path
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// HashLockfile returns the current hash of the project's lockfile, or ""
+// when it has none or it can't be read
+func HashLockfile(p *models.Project) string {
+	if p.Lockfile == "" {
+		return ""
+	}
+	hash, _ := hashFile(filepath.Join(p.Root, p.Lockfile))
+	return hash
+}
+
+// Detector finds the project of each folder, reading every directory and
+// manifest once. Safe for concurrent use.
+type Detector struct {
+	mu       sync.Mutex
+	projects map[string]*models.Project // by directory and folder type
+}
+
+func NewDetector() *Detector {
+	return &Detector{projects: make(map[string]*models.Project)}
+}
+
+// Detect walks up from the folder's parent to the nearest directory with
+// a manifest and returns its project, or nil when there is none
+func (d *Detector) Detect(folder models.DependencyFolder) *models.Project {
+	for dir := filepath.Dir(folder.Path); ; dir = filepath.Dir(dir) {
+		if p := d.load(dir, folder.Type); p != nil {
+			return p
+		}
+		if parent := filepath.Dir(dir); parent == dir {
+			return nil
+		}
+	}
+}
+
+func (d *Detector) load(dir, folderType string) *models.Project {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	key := dir + "\x00" + folderType
+	if p, ok := d.projects[key]; ok {
+		return p
+	}
+	p := Load(dir, folderType)
+	d.projects[key] = p
+	return p
+}
+
+// Group is a project, or a workspace of projects, and the dependency
+// folders found in it
+type Group struct {
+	Project   *models.Project   // nil for folders outside any project
+	Workspace *models.Workspace // set when the folders belong to a workspace
+	Folders   []models.DependencyFolder
+	Size      int64
+}
+
+// Root is the directory the group's folder paths are relative to, or ""
+// for folders outside any project
+func (g Group) Root() string {
+	switch {
+	case g.Workspace != nil:
+		return g.Workspace.Root
+	case g.Project != nil:
+		return g.Project.Root
+	}
+	return ""
+}
+
+// Label names the group like "my-app (node, pnpm)", or "my-repo (node,
+// pnpm workspace)" for a workspace
+func (g Group) Label() string {
+	switch {
+	case g.Workspace != nil:
+		ecosystem := "node"
+		if g.Workspace.Kind == "cargo" {
+			ecosystem = "rust"
+		}
+		return g.Workspace.Name + " (" + ecosystem + ", " + g.Workspace.Kind + " workspace)"
+	case g.Project != nil:
+		return g.Project.Name + " (" + g.Project.Ecosystem + ", " + g.Project.PackageManager + ")"
+	}
+	return "(no project)"
+}
+
+// GroupFolders groups folders by their Workspace, or their Project when
+// they are not in one, largest group first. Folders without a project are
+// grouped on their own at the end.
+func GroupFolders(folders []models.DependencyFolder) []Group {
+	index := make(map[string]int)
+	var groups []Group
+	var loose Group
+
+	for _, f := range folders {
+		var key string
+		switch {
+		case f.Workspace != nil:
+			key = "workspace\x00" + f.Workspace.Root + "\x00" + f.Workspace.Kind
+		case f.Project != nil:
+			key = f.Project.Root + "\x00" + f.Project.Manifest
+		default:
+			loose.Folders = append(loose.Folders, f)
+			loose.Size += f.Size
+			continue
+		}
+
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, Group{Project: f.Project, Workspace: f.Workspace})
+		}
+		groups[i].Folders = append(groups[i].Folders, f)
+		groups[i].Size += f.Size
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool { return groups[i].Size > groups[j].Size })
+	if len(loose.Folders) > 0 {
+		groups = append(groups, loose)
+	}
+	return groups
+}
@@ -0,0 +1,129 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/d4rthvadr/node-cleaner/pkg/models"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		folder  string
+		typ     string
+		want    models.Project
+		hasLock bool
+	}{
+		{
+			name: "pnpm",
+			files: map[string]string{
+				"package.json":   `{"name": "my-app", "version": "1.2.0"}`,
+				"pnpm-lock.yaml": "lockfileVersion: '9.0'",
+			},
+			folder: "node_modules", typ: "Node.js",
+			want:    models.Project{Manifest: "package.json", Ecosystem: "node", Name: "my-app", Version: "1.2.0", PackageManager: "pnpm", Lockfile: "pnpm-lock.yaml"},
+			hasLock: true,
+		},
+		{
+			name:   "Declared package manager without lockfile",
+			files:  map[string]string{"package.json": `{"name": "web", "packageManager": "yarn@4.1.0"}`},
+			folder: "node_modules", typ: "Node.js",
+			want: models.Project{Manifest: "package.json", Ecosystem: "node", Name: "web", PackageManager: "yarn"},
+		},
+		{
+			name: "pnpm workspace without lockfile",
+			files: map[string]string{
+				"package.json":        `{"name": "mono"}`,
+				"pnpm-workspace.yaml": "packages:\n  - packages/*\n",
+			},
+			folder: "node_modules", typ: "Node.js",
+			want: models.Project{Manifest: "package.json", Ecosystem: "node", Name: "mono", PackageManager: "pnpm"},
+		},
+		{
+			name: "uv in a nested cache folder",
+			files: map[string]string{
+				"pyproject.toml": "[project]\nname = \"svc\"\nversion = \"0.3.0\"\n",
+				"uv.lock":        "version = 1",
+			},
+			folder: "src/svc/__pycache__", typ: "Python",
+			want:    models.Project{Manifest: "pyproject.toml", Ecosystem: "python", Name: "svc", Version: "0.3.0", PackageManager: "uv", Lockfile: "uv.lock"},
+			hasLock: true,
+		},
+		{
+			name:   "Poetry",
+			files:  map[string]string{"pyproject.toml": "[tool.poetry]\nname = \"tool\"\nversion = \"2.0.0\"\n"},
+			folder: ".venv", typ: "Python",
+			want: models.Project{Manifest: "pyproject.toml", Ecosystem: "python", Name: "tool", Version: "2.0.0", PackageManager: "poetry"},
+		},
+		{
+			name: "Cargo with a workspace version",
+			files: map[string]string{
+				"Cargo.toml": "[package]\nname = \"cli\"\nversion.workspace = true\n",
+				"Cargo.lock": "version = 3",
+			},
+			folder: "target", typ: "Rust",
+			want:    models.Project{Manifest: "Cargo.toml", Ecosystem: "rust", Name: "cli", PackageManager: "cargo", Lockfile: "Cargo.lock"},
+			hasLock: true,
+		},
+		{
+			name: "Folder type picks the manifest",
+			files: map[string]string{
+				"package.json":   `{"name": "mixed"}`,
+				"pyproject.toml": "[project]\nname = \"mixed-py\"\n",
+			},
+			folder: ".venv", typ: "Python",
+			want: models.Project{Manifest: "pyproject.toml", Ecosystem: "python", Name: "mixed-py", PackageManager: "pip"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			for name, content := range tt.files {
+				if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got := NewDetector().Detect(models.DependencyFolder{Path: filepath.Join(root, tt.folder), Type: tt.typ})
+			if got == nil {
+				t.Fatal("Detect() = nil; want a project")
+			}
+
+			tt.want.Root = root
+			hash := got.LockfileHash
+			got.LockfileHash = ""
+			if *got != tt.want {
+				t.Errorf("Detect() = %+v; want %+v", *got, tt.want)
+			}
+			if tt.hasLock && (len(hash) != 64 || hash != HashLockfile(got)) {
+				t.Errorf("LockfileHash = %q; want the sha256 of %s", hash, tt.want.Lockfile)
+			}
+		})
+	}
+}
+
+func TestGroupFolders(t *testing.T) {
+	app := &models.Project{Root: "/p/app", Manifest: "package.json", Name: "app"}
+	lib := &models.Project{Root: "/p/lib", Manifest: "Cargo.toml", Name: "lib"}
+
+	groups := GroupFolders([]models.DependencyFolder{
+		{Path: "/p/app/node_modules", Size: 100, Project: app},
+		{Path: "/tmp/x/venv", Size: 900},
+		{Path: "/p/lib/target", Size: 300, Project: lib},
+		{Path: "/p/app/web/node_modules", Size: 50, Project: app},
+	})
+
+	if len(groups) != 3 {
+		t.Fatalf("got %d groups; want 3", len(groups))
+	}
+	if groups[0].Project != lib || groups[1].Project != app || groups[2].Project != nil {
+		t.Errorf("groups not ordered by size with loose folders last: %+v", groups)
+	}
+	if groups[1].Size != 150 || len(groups[1].Folders) != 2 {
+		t.Errorf("app group = %d bytes in %d folders; want 150 in 2", groups[1].Size, len(groups[1].Folders))
+	}
+}
@@ -0,0 +1,296 @@
+// Package quarantine holds cleaned folders aside instead of deleting them,
+// giving an undo window before they are purged for real.
+package quarantine
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/d4rthvadr/node-cleaner/pkg/utils"
+)
+
+// ErrNotFound is returned when no quarantined folder matches an id or path
+var ErrNotFound = errors.New("not found in quarantine")
+
+// Entry describes a folder held in quarantine
+type Entry struct {
+	ID            string    `json:"id"`
+	OriginalPath  string    `json:"original_path"`
+	StoredPath    string    `json:"stored_path"`
+	Size          int64     `json:"size"`
+	QuarantinedAt time.Time `json:"quarantined_at"`
+}
+
+type manifest struct {
+	Entries []Entry `json:"entries"`
+}
+
+// Store manages the quarantine area and its manifest.
+// Folders on the same filesystem as root are kept under root; folders on
+// other filesystems go to a per-user directory at the top of their mount so
+// moving them in and out is always a single atomic rename.
+type Store struct {
+	root string
+}
+
+// NewStore returns a Store rooted at dir (e.g. ~/.depocleaner/quarantine)
+func NewStore(dir string) *Store {
+	return &Store{root: dir}
+}
+
+func (s *Store) manifestPath() string {
+	return filepath.Join(s.root, "manifest.json")
+}
+
+// Add moves path into quarantine and records it in the manifest
+func (s *Store) Add(path string, size int64) (*Entry, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(s.root, 0700); err != nil {
+		return nil, fmt.Errorf("creating quarantine directory: %w", err)
+	}
+
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+
+	holdDir := filepath.Join(s.areaFor(abs), id)
+	if err := os.MkdirAll(holdDir, 0700); err != nil {
+		return nil, fmt.Errorf("creating quarantine directory: %w", err)
+	}
+
+	entry := Entry{
+		ID:            id,
+		OriginalPath:  abs,
+		StoredPath:    filepath.Join(holdDir, filepath.Base(abs)),
+		Size:          size,
+		QuarantinedAt: time.Now(),
+	}
+
+	if err := os.Rename(abs, entry.StoredPath); err != nil {
+		os.Remove(holdDir)
+		return nil, err
+	}
+
+	err = s.update(func(m *manifest) error {
+		m.Entries = append(m.Entries, entry)
+		return nil
+	})
+	if err != nil {
+		// put the folder back rather than leave it untracked
+		os.Rename(entry.StoredPath, abs)
+		os.Remove(holdDir)
+		return nil, err
+	}
+
+	return &entry, nil
+}
+
+// areaFor picks the quarantine directory on the same filesystem as abs
+func (s *Store) areaFor(abs string) string {
+	dev := utils.DeviceOf(filepath.Dir(abs))
+	if utils.DeviceOf(s.root) == dev {
+		return s.root
+	}
+	top := utils.MountTop(filepath.Dir(abs), dev)
+	return filepath.Join(top, ".depocleaner-quarantine-"+strconv.Itoa(os.Getuid()))
+}
+
+// List returns all quarantined folders, oldest first
+func (s *Store) List() ([]Entry, error) {
+	lock, err := s.lock(false)
+	if err != nil {
+		return nil, err
+	}
+	defer lock.Release()
+
+	m, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(m.Entries, func(i, j int) bool {
+		return m.Entries[i].QuarantinedAt.Before(m.Entries[j].QuarantinedAt)
+	})
+	return m.Entries, nil
+}
+
+// Restore moves a quarantined folder, found by id or original path,
+// back to where it came from
+func (s *Store) Restore(idOrPath string) (*Entry, error) {
+	var restored *Entry
+
+	err := s.update(func(m *manifest) error {
+		idx := find(m.Entries, idOrPath)
+		if idx < 0 {
+			return fmt.Errorf("%s: %w", idOrPath, ErrNotFound)
+		}
+		entry := m.Entries[idx]
+
+		if _, err := os.Lstat(entry.OriginalPath); err == nil {
+			return fmt.Errorf("%s already exists, not overwriting it", entry.OriginalPath)
+		}
+		if err := os.MkdirAll(filepath.Dir(entry.OriginalPath), 0755); err != nil {
+			return err
+		}
+		if err := os.Rename(entry.StoredPath, entry.OriginalPath); err != nil {
+			return err
+		}
+		os.Remove(filepath.Dir(entry.StoredPath))
+
+		m.Entries = append(m.Entries[:idx], m.Entries[idx+1:]...)
+		restored = &entry
+		return nil
+	})
+
+	return restored, err
+}
+
+// Purge permanently deletes folders quarantined more than olderThan ago
+func (s *Store) Purge(olderThan time.Duration) ([]Entry, error) {
+	var purged []Entry
+	cutoff := time.Now().Add(-olderThan)
+
+	err := s.update(func(m *manifest) error {
+		kept := m.Entries[:0]
+		var errs []error
+
+		for _, entry := range m.Entries {
+			if entry.QuarantinedAt.After(cutoff) {
+				kept = append(kept, entry)
+				continue
+			}
+			hold, err := s.holdDir(entry)
+			if err == nil {
+				// quarantined trees can be read-only, like the Go module cache
+				err = utils.RemoveTree(context.Background(), hold, nil)
+			}
+			if err != nil {
+				errs = append(errs, fmt.Errorf("purging %s: %w", entry.ID, err))
+				kept = append(kept, entry)
+				continue
+			}
+			purged = append(purged, entry)
+		}
+
+		m.Entries = kept
+		return errors.Join(errs...)
+	})
+
+	return purged, err
+}
+
+// holdDir returns the directory Add created for entry, refusing manifest
+// entries whose stored path does not point into a quarantine area, so a
+// damaged manifest can never make Purge delete anything else
+func (s *Store) holdDir(entry Entry) (string, error) {
+	hold := filepath.Dir(entry.StoredPath)
+	if !filepath.IsAbs(entry.StoredPath) || entry.ID == "" || filepath.Base(hold) != entry.ID {
+		return "", fmt.Errorf("stored path %q is not in the quarantine", entry.StoredPath)
+	}
+
+	area := filepath.Dir(hold)
+	root, err := filepath.Abs(s.root)
+	if err != nil {
+		return "", err
+	}
+	if area != root && filepath.Base(area) != ".depocleaner-quarantine-"+strconv.Itoa(os.Getuid()) {
+		return "", fmt.Errorf("stored path %q is not in the quarantine", entry.StoredPath)
+	}
+	return hold, nil
+}
+
+// find returns the index of the entry matching an id or original path
+func find(entries []Entry, idOrPath string) int {
+	abs, _ := filepath.Abs(idOrPath)
+
+	// prefer the most recent entry when a path was quarantined more than once
+	for i := len(entries) - 1; i >= 0; i-- {
+		if entries[i].ID == idOrPath || entries[i].OriginalPath == abs {
+			return i
+		}
+	}
+	return -1
+}
+
+func (s *Store) lock(exclusive bool) (*utils.FileLock, error) {
+	if err := os.MkdirAll(s.root, 0700); err != nil {
+		return nil, err
+	}
+	return utils.AcquireLock(s.manifestPath()+".lock", exclusive)
+}
+
+func (s *Store) read() (*manifest, error) {
+	m := &manifest{}
+
+	data, err := os.ReadFile(s.manifestPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("reading quarantine manifest: %w", err)
+	}
+	return m, nil
+}
+
+// update applies fn to the manifest under an exclusive lock and writes it back.
+// The manifest is still written when fn fails part way (e.g. a partial purge).
+func (s *Store) update(fn func(m *manifest) error) error {
+	lock, err := s.lock(true)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	m, err := s.read()
+	if err != nil {
+		return err
+	}
+
+	fnErr := fn(m)
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(s.root, "manifest.*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), s.manifestPath())
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+
+	return fnErr
+}
+
+func newID() (string, error) {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,151 @@
+package quarantine
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newFolder(t *testing.T, dir string) string {
+	t.Helper()
+	folder := filepath.Join(dir, "app", "node_modules")
+	if err := os.MkdirAll(folder, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(folder, "index.js"), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return folder
+}
+
+func TestAddAndRestore(t *testing.T) {
+	dir := t.TempDir()
+	store := NewStore(filepath.Join(dir, "quarantine"))
+	folder := newFolder(t, dir)
+
+	entry, err := store.Add(folder, 1)
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if _, err := os.Stat(folder); !os.IsNotExist(err) {
+		t.Fatalf("folder still in place after Add(): %v", err)
+	}
+
+	entries, err := store.List()
+	if err != nil || len(entries) != 1 || entries[0].ID != entry.ID {
+		t.Fatalf("List() = %+v, %v; want the added entry", entries, err)
+	}
+
+	if _, err := store.Restore(entry.ID); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(folder, "index.js")); err != nil {
+		t.Errorf("restored folder incomplete: %v", err)
+	}
+
+	entries, _ = store.List()
+	if len(entries) != 0 {
+		t.Errorf("List() after restore = %+v; want empty", entries)
+	}
+}
+
+func TestRestoreByPathRefusesToOverwrite(t *testing.T) {
+	dir := t.TempDir()
+	store := NewStore(filepath.Join(dir, "quarantine"))
+	folder := newFolder(t, dir)
+
+	if _, err := store.Add(folder, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(folder, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := store.Restore(folder); err == nil {
+		t.Error("Restore() over an existing folder succeeded; want error")
+	}
+	if _, err := store.Restore("missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Restore(missing) error = %v; want ErrNotFound", err)
+	}
+}
+
+func TestPurgeOlderThan(t *testing.T) {
+	dir := t.TempDir()
+	store := NewStore(filepath.Join(dir, "quarantine"))
+
+	old, err := store.Add(newFolder(t, filepath.Join(dir, "old")), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Add(newFolder(t, filepath.Join(dir, "new")), 1); err != nil {
+		t.Fatal(err)
+	}
+
+	// age the first entry past the purge window
+	store.update(func(m *manifest) error {
+		m.Entries[0].QuarantinedAt = time.Now().Add(-8 * 24 * time.Hour)
+		return nil
+	})
+
+	purged, err := store.Purge(7 * 24 * time.Hour)
+	if err != nil {
+		t.Fatalf("Purge() error = %v", err)
+	}
+	if len(purged) != 1 || purged[0].ID != old.ID {
+		t.Fatalf("Purge() = %+v; want only the old entry", purged)
+	}
+	if _, err := os.Stat(old.StoredPath); !os.IsNotExist(err) {
+		t.Errorf("purged folder still on disk: %v", err)
+	}
+
+	entries, _ := store.List()
+	if len(entries) != 1 {
+		t.Errorf("List() after purge = %d entries; want 1", len(entries))
+	}
+}
+
+func TestPurgeRefusesPathsOutsideQuarantine(t *testing.T) {
+	dir := t.TempDir()
+	store := NewStore(filepath.Join(dir, "quarantine"))
+	victim := newFolder(t, filepath.Join(dir, "victim"))
+
+	for _, stored := range []string{"", "node_modules", victim, filepath.Join(dir, "abc", "node_modules")} {
+		store.update(func(m *manifest) error {
+			m.Entries = []Entry{{ID: "abc", StoredPath: stored, QuarantinedAt: time.Now().Add(-time.Hour)}}
+			return nil
+		})
+
+		if purged, err := store.Purge(0); err == nil || len(purged) != 0 {
+			t.Errorf("Purge() of stored path %q = %v, %v; want it refused", stored, purged, err)
+		}
+		if _, err := os.Stat(victim); err != nil {
+			t.Fatalf("Purge() of stored path %q removed a folder outside the quarantine: %v", stored, err)
+		}
+	}
+}
+
+func TestPurgeReadOnlyTree(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("permission bits do not restrict root")
+	}
+	dir := t.TempDir()
+	store := NewStore(filepath.Join(dir, "quarantine"))
+
+	entry, err := store.Add(newFolder(t, dir), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// lock the tree down like the Go module cache does
+	if err := os.Chmod(entry.StoredPath, 0555); err != nil {
+		t.Fatal(err)
+	}
+
+	if purged, err := store.Purge(0); err != nil || len(purged) != 1 {
+		t.Fatalf("Purge() = %v, %v; want the entry purged", purged, err)
+	}
+	if _, err := os.Stat(filepath.Dir(entry.StoredPath)); !os.IsNotExist(err) {
+		t.Errorf("purged folder still on disk: %v", err)
+	}
+}
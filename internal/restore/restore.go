@@ -0,0 +1,304 @@
+// Package restore keeps a central index of how to reinstall the
+// dependencies of cleaned projects: the package manager, the lockfile they
+// were installed from and the exact command that brings them back.
+package restore
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/d4rthvadr/node-cleaner/internal/project"
+	"github.com/d4rthvadr/node-cleaner/pkg/models"
+	"github.com/d4rthvadr/node-cleaner/pkg/utils"
+)
+
+// ErrNotFound is returned when no cleaned project matches a path or name
+var ErrNotFound = errors.New("no restore hint recorded")
+
+// Hint records how to reinstall the dependencies of one cleaned project
+type Hint struct {
+	Project        string    `json:"project"` // directory the command runs in
+	Name           string    `json:"name"`
+	Ecosystem      string    `json:"ecosystem"`
+	PackageManager string    `json:"package_manager"`
+	Lockfile       string    `json:"lockfile,omitempty"`
+	LockfileHash   string    `json:"lockfile_hash,omitempty"`
+	Command        string    `json:"command"`
+	Folders        []string  `json:"folders"`
+	CleanedAt      time.Time `json:"cleaned_at"`
+}
+
+// LockfileChanged reports whether the project's lockfile differs from the
+// one recorded at clean time, including when it was deleted since.
+// Hints recorded without a lockfile never report a change.
+func (h Hint) LockfileChanged() bool {
+	if h.LockfileHash == "" {
+		return false
+	}
+	current := project.HashLockfile(&models.Project{Root: h.Project, Lockfile: h.Lockfile})
+	return current != h.LockfileHash
+}
+
+// Command returns the command that reinstalls folder for project p, run
+// from the project root, or "" when the folder is a cache that rebuilds
+// itself
+func Command(p *models.Project, folder string) string {
+	name := filepath.Base(folder)
+	locked := p.Lockfile != ""
+
+	switch name {
+	case "__pycache__", "node_modules_cache":
+		return ""
+	}
+
+	switch p.PackageManager {
+	case "npm":
+		if locked {
+			return "npm ci"
+		}
+		return "npm install"
+	case "pnpm":
+		if locked {
+			return "pnpm install --frozen-lockfile"
+		}
+		return "pnpm install"
+	case "yarn":
+		switch {
+		case !locked:
+			return "yarn install"
+		case fileExists(filepath.Join(p.Root, ".yarnrc.yml")):
+			// yarn 2+ renamed the flag
+			return "yarn install --immutable"
+		}
+		return "yarn install --frozen-lockfile"
+	case "bun":
+		if locked {
+			return "bun install --frozen-lockfile"
+		}
+		return "bun install"
+	case "uv":
+		return "uv sync"
+	case "poetry":
+		return "poetry install"
+	case "pipenv":
+		if locked {
+			return "pipenv sync"
+		}
+		return "pipenv install"
+	case "pip":
+		install := "pip install ."
+		if p.Manifest == "requirements.txt" {
+			install = "pip install -r requirements.txt"
+		}
+		if rel, err := filepath.Rel(p.Root, folder); err == nil && (name == ".venv" || name == "venv") {
+			return "python3 -m venv " + rel + " && " + rel + "/bin/" + install
+		}
+		return install
+	case "cargo":
+		return "cargo build"
+	case "composer":
+		return "composer install"
+	case "go":
+		return "go mod vendor"
+	}
+	return ""
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// FromClean builds one hint per project for the folders a clean run
+// removed. Workspace members are recorded under the workspace root, where
+// their shared install runs. Folders outside any project are skipped.
+func FromClean(folders []models.DependencyFolder, result *models.CleanResult) []Hint {
+	if result.DryRun {
+		return nil
+	}
+
+	removed := make(map[string]bool, len(result.DeletedFolders))
+	for _, path := range result.DeletedFolders {
+		removed[path] = true
+	}
+
+	now := time.Now()
+	index := make(map[string]int)
+	var hints []Hint
+
+	for _, f := range folders {
+		if !removed[f.Path] {
+			continue
+		}
+
+		p := f.Project
+		if f.Workspace != nil {
+			if root := project.Load(f.Workspace.Root, f.Type); root != nil {
+				p = root
+			}
+		}
+		if p == nil {
+			continue
+		}
+		command := Command(p, f.Path)
+		if command == "" {
+			continue
+		}
+
+		key := p.Root + "\x00" + p.Ecosystem
+		i, ok := index[key]
+		if !ok {
+			i = len(hints)
+			index[key] = i
+			hints = append(hints, Hint{
+				Project:        p.Root,
+				Name:           p.Name,
+				Ecosystem:      p.Ecosystem,
+				PackageManager: p.PackageManager,
+				Lockfile:       p.Lockfile,
+				LockfileHash:   p.LockfileHash,
+				Command:        command,
+				CleanedAt:      now,
+			})
+		}
+		hints[i].Folders = append(hints[i].Folders, f.Path)
+	}
+	return hints
+}
+
+// Index stores the latest hint of every cleaned project in a JSON file.
+// A directory with projects of several ecosystems has a hint for each.
+type Index struct {
+	path string
+}
+
+// NewIndex returns an index stored at path
+// (e.g. ~/.depocleaner/restore.json)
+func NewIndex(path string) *Index {
+	return &Index{path: path}
+}
+
+// Record saves hints, replacing earlier hints for the same projects.
+// Folders of an earlier hint that are still missing are carried over, so
+// the hint covers everything cleaned since the last reinstall.
+func (x *Index) Record(hints []Hint) error {
+	if len(hints) == 0 {
+		return nil
+	}
+
+	return x.update(func(entries []Hint) []Hint {
+		for _, h := range hints {
+			i := slices.IndexFunc(entries, func(e Hint) bool {
+				return e.Project == h.Project && e.Ecosystem == h.Ecosystem
+			})
+			if i < 0 {
+				sort.Strings(h.Folders)
+				entries = append(entries, h)
+				continue
+			}
+			for _, folder := range entries[i].Folders {
+				if !fileExists(folder) && !slices.Contains(h.Folders, folder) {
+					h.Folders = append(h.Folders, folder)
+				}
+			}
+			sort.Strings(h.Folders)
+			entries[i] = h
+		}
+		return entries
+	})
+}
+
+// Find returns the hints for a project given its name or a path inside it,
+// or for every project under a directory, most recently cleaned first
+func (x *Index) Find(query string) ([]Hint, error) {
+	lock, err := x.lock(false)
+	if err != nil {
+		return nil, err
+	}
+	defer lock.Release()
+
+	entries, err := x.read()
+	if err != nil {
+		return nil, err
+	}
+
+	abs, _ := filepath.Abs(query)
+	var found []Hint
+	for _, h := range entries {
+		if h.Name == query || utils.IsWithin(abs, h.Project) || utils.IsWithin(h.Project, abs) {
+			found = append(found, h)
+		}
+	}
+	if len(found) == 0 {
+		return nil, fmt.Errorf("%s: %w", query, ErrNotFound)
+	}
+
+	sort.Slice(found, func(i, j int) bool { return found[i].CleanedAt.After(found[j].CleanedAt) })
+	return found, nil
+}
+
+func (x *Index) lock(exclusive bool) (*utils.FileLock, error) {
+	if err := os.MkdirAll(filepath.Dir(x.path), 0755); err != nil {
+		return nil, err
+	}
+	return utils.AcquireLock(x.path+".lock", exclusive)
+}
+
+func (x *Index) read() ([]Hint, error) {
+	var entries []Hint
+
+	data, err := os.ReadFile(x.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("reading restore index: %w", err)
+	}
+	return entries, nil
+}
+
+// update applies fn to the index under an exclusive lock and writes the
+// result back atomically
+func (x *Index) update(fn func(entries []Hint) []Hint) error {
+	lock, err := x.lock(true)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	entries, err := x.read()
+	if err != nil {
+		return err
+	}
+	entries = fn(entries)
+
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(x.path), filepath.Base(x.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), x.path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
@@ -0,0 +1,97 @@
+package restore
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/d4rthvadr/node-cleaner/internal/project"
+	"github.com/d4rthvadr/node-cleaner/pkg/models"
+)
+
+func TestCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		project models.Project
+		folder  string
+		want    string
+	}{
+		{"npm", models.Project{PackageManager: "npm", Lockfile: "package-lock.json"}, "node_modules", "npm ci"},
+		{"npm without lockfile", models.Project{PackageManager: "npm"}, "node_modules", "npm install"},
+		{"pnpm", models.Project{PackageManager: "pnpm", Lockfile: "pnpm-lock.yaml"}, "node_modules", "pnpm install --frozen-lockfile"},
+		{"yarn classic", models.Project{PackageManager: "yarn", Lockfile: "yarn.lock"}, "node_modules", "yarn install --frozen-lockfile"},
+		{"uv", models.Project{PackageManager: "uv", Lockfile: "uv.lock"}, ".venv", "uv sync"},
+		{"pip venv", models.Project{PackageManager: "pip", Manifest: "requirements.txt"}, "venv", "python3 -m venv venv && venv/bin/pip install -r requirements.txt"},
+		{"cargo", models.Project{PackageManager: "cargo", Lockfile: "Cargo.lock"}, "target", "cargo build"},
+		{"Cache rebuilds itself", models.Project{PackageManager: "pip"}, "src/__pycache__", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.project.Root = "/p"
+			if got := Command(&tt.project, filepath.Join("/p", tt.folder)); got != tt.want {
+				t.Errorf("Command() = %q; want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIndex(t *testing.T) {
+	root := t.TempDir()
+	lockfile := filepath.Join(root, "pnpm-lock.yaml")
+	if err := os.WriteFile(lockfile, []byte("lockfileVersion: '9.0'"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	p := &models.Project{Root: root, Name: "app", Ecosystem: "node", PackageManager: "pnpm", Lockfile: "pnpm-lock.yaml"}
+	p.LockfileHash = project.HashLockfile(p)
+
+	folders := []models.DependencyFolder{
+		{Path: filepath.Join(root, "node_modules"), Type: "Node.js", Project: p},
+		{Path: filepath.Join(root, "web", "node_modules"), Type: "Node.js", Project: p},
+		{Path: "/elsewhere/node_modules", Type: "Node.js"},
+	}
+	index := NewIndex(filepath.Join(t.TempDir(), "restore.json"))
+
+	if hints := FromClean(folders, &models.CleanResult{DryRun: true, DeletedFolders: []string{folders[0].Path}}); hints != nil {
+		t.Errorf("FromClean() of a dry run = %+v; want nil", hints)
+	}
+
+	// two runs, each cleaning one folder of the project
+	for _, f := range folders[:2] {
+		hints := FromClean(folders, &models.CleanResult{DeletedFolders: []string{f.Path, folders[2].Path}})
+		if len(hints) != 1 {
+			t.Fatalf("FromClean() = %d hints; want 1 for the project", len(hints))
+		}
+		if err := index.Record(hints); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	hints, err := index.Find(filepath.Join(root, "web"))
+	if err != nil || len(hints) != 1 {
+		t.Fatalf("Find() = %+v, %v; want one hint", hints, err)
+	}
+	h := hints[0]
+	if h.Command != "pnpm install --frozen-lockfile" || !slices.Equal(h.Folders, []string{folders[0].Path, folders[1].Path}) {
+		t.Errorf("Find() = %+v; want both folders with the pnpm command", h)
+	}
+	if h.LockfileChanged() {
+		t.Error("LockfileChanged() = true before the lockfile changed")
+	}
+
+	if err := os.WriteFile(lockfile, []byte("lockfileVersion: '9.1'"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if !h.LockfileChanged() {
+		t.Error("LockfileChanged() = false after the lockfile changed")
+	}
+
+	if _, err := index.Find("app"); err != nil {
+		t.Errorf("Find() by name error = %v", err)
+	}
+	if _, err := index.Find("other"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Find() of an unknown project error = %v; want ErrNotFound", err)
+	}
+}
@@ -9,6 +9,13 @@ import (
 	"time"
 
 	"github.com/d4rthvadr/node-cleaner/internal/analyzer"
+	"github.com/d4rthvadr/node-cleaner/internal/gitrepo"
+	"github.com/d4rthvadr/node-cleaner/internal/logger"
+	"github.com/d4rthvadr/node-cleaner/internal/marker"
+	"github.com/d4rthvadr/node-cleaner/internal/policy"
+	"github.com/d4rthvadr/node-cleaner/internal/project"
+	"github.com/d4rthvadr/node-cleaner/internal/staleness"
+	"github.com/d4rthvadr/node-cleaner/internal/workspace"
 	"github.com/d4rthvadr/node-cleaner/pkg/models"
 	"github.com/d4rthvadr/node-cleaner/pkg/utils"
 )
@@ -20,6 +27,9 @@ type Scanner struct {
 	errors    chan error
 	analyzer  *analyzer.Analyzer
 	workQueue chan string
+	logger    logger.Logger
+	// policy marks folders matched by protect rules as protected
+	policy *policy.Policy
 }
 
 type CacheProvider interface {
@@ -37,18 +47,38 @@ func NewScanner(cfg *models.Config, cache CacheProvider) *Scanner {
 		cache:    cache,
 		results:  make(chan models.DependencyFolder, 100), // buffered to prevent blocking
 		errors:   make(chan error, 50),                    // buffered for errors
+		logger:   logger.Discard(),
 	}
 }
 
+// SetLogger sets the logger used by the scanner and its analyzer
+func (s *Scanner) SetLogger(l logger.Logger) {
+	s.logger = l
+	s.analyzer.SetLogger(l)
+}
+
+// SetPolicy makes the scan mark folders that a protect rule of p matches
+// as protected, the same way project markers do. A nil policy disables it.
+func (s *Scanner) SetPolicy(p *policy.Policy) {
+	s.policy = p
+}
+
 // Scan initiates file traversal process
 func (s *Scanner) Scan(ctx context.Context, rootPath string) (*models.ScanResult, error) {
 
+	// folder paths are compared with absolute ones (markers, /proc, the
+	// cleaner's roots), so never report them relative to the cwd
+	rootPath, err := filepath.Abs(rootPath)
+	if err != nil {
+		return nil, fmt.Errorf("resolving scan path: %w", err)
+	}
+
 	finalResult := &models.ScanResult{
 		ScanPath: rootPath,
 		ScanTime: time.Now(),
 	}
 
-	fmt.Println("Starting scan on path:", rootPath)
+	s.logger.Info("scan started", "path", rootPath, "workers", s.config.Workers, "max_depth", s.config.MaxDepth, "cache", s.cache != nil)
 
 	s.workQueue = make(chan string, s.config.Workers*2) // buffered channel
 
@@ -66,9 +96,8 @@ func (s *Scanner) Scan(ctx context.Context, rootPath string) (*models.ScanResult
 	go func() {
 		if err := s.walkFileSystem(ctx, rootPath, 0); err != nil {
 			s.errors <- fmt.Errorf("walking filesystem: %w", err)
-			fmt.Printf("error occured %v", err)
 		}
-		fmt.Println("file system walk completed")
+		s.logger.Debug("file system walk completed", "path", rootPath)
 		close(s.workQueue)
 	}()
 
@@ -83,6 +112,7 @@ func (s *Scanner) Scan(ctx context.Context, rootPath string) (*models.ScanResult
 	go func() {
 		for err := range s.errors {
 			// Log errors (could be aggregated or handled differently)
+			s.logger.Warn("scan error", "error", err)
 			fmt.Printf("Scan error: %v\n", err)
 		}
 		done <- struct{}{}
@@ -90,7 +120,28 @@ func (s *Scanner) Scan(ctx context.Context, rootPath string) (*models.ScanResult
 
 	// aggregate results and errors concurrently
 
+	markers := marker.NewFinder(func(err error) {
+		s.logger.Warn("invalid protection marker, protecting its project", "error", err)
+	})
+	repos := gitrepo.NewInfoCache()
+	projects := project.NewDetector()
+	workspaces := workspace.NewFinder()
+	scorer := staleness.NewScorer(finalResult.ScanTime)
 	for r := range s.results {
+		// markers and scores are worked out here rather than in the walk so
+		// that cached folders reflect new markers and recent git activity
+		if r.Protected = markers.Protects(r, finalResult.ScanTime); r.Protected != "" {
+			s.logger.Debug("folder protected by marker", "path", r.Path, "reason", r.Protected)
+		} else if s.policy != nil {
+			if r.Protected = s.policy.Protects(r, finalResult.ScanTime); r.Protected != "" {
+				s.logger.Debug("folder protected by policy", "path", r.Path, "reason", r.Protected)
+			}
+		}
+		r.Git = repos.Lookup(filepath.Dir(r.Path))
+		r.Project = projects.Detect(r)
+		r.Workspace = workspaces.Find(r)
+		score := scorer.Score(r)
+		r.Score, r.ScoreReasons = score.Score, score.Reasons
 		finalResult.Folders = append(finalResult.Folders, r)
 		finalResult.TotalSize += r.Size
 		finalResult.TotalCount++
@@ -98,7 +149,17 @@ func (s *Scanner) Scan(ctx context.Context, rootPath string) (*models.ScanResult
 
 	<-done // wait for error processing to complete
 
+	// saving merges with the file on disk, so do it once rather than per folder
+	if s.cache != nil {
+		if err := s.cache.Save(); err != nil {
+			s.logger.Warn("failed to save cache", "error", err)
+			fmt.Printf("failed to save cache: %v\n", err)
+		}
+	}
+
 	finalResult.Duration = time.Since(finalResult.ScanTime)
+	s.logger.Info("scan finished", "path", rootPath, "folders", finalResult.TotalCount,
+		"total_size", finalResult.TotalSize, "duration", finalResult.Duration)
 	return finalResult, nil
 
 }
@@ -140,6 +201,11 @@ func (s *Scanner) walkFileSystem(ctx context.Context, rootPath string, depth int
 
 		// TODO: check ignore paths
 
+		// folders renamed for deletion are left to the reaper
+		if utils.IsTombstone(d.Name()) {
+			return fs.SkipDir
+		}
+
 		if utils.IsTargetDirectory(d.Name()) {
 
 			info, _ := d.Info()
@@ -147,14 +213,15 @@ func (s *Scanner) walkFileSystem(ctx context.Context, rootPath string, depth int
 			if s.cache != nil && s.cache.IsValid(path, info.ModTime()) {
 
 				// use cached data
-				cached, cacheHit := s.cache.Get(path)
-				fmt.Println("cache hit? ", cacheHit)
+				cached, _ := s.cache.Get(path)
+				s.logger.Debug("cache hit", "path", path)
 				s.results <- models.DependencyFolder{
 					Path:         path,
 					AbsolutePath: path,
 					Size:         cached.Size,
 					ModTime:      cached.ModTime,
 					Type:         utils.DetectType(d.Name()),
+					Tracked:      s.analyzer.IsTracked(path),
 				}
 
 			} else {
@@ -219,10 +286,6 @@ func (s *Scanner) worker(ctx context.Context, wg *sync.WaitGroup) {
 					ModTime:  folder.ModTime,
 					LastScan: time.Now(),
 				})
-				err := s.cache.Save()
-				if err != nil {
-					fmt.Printf("failed to save cache: %v\n", err)
-				}
 			}
 
 			// context could cancelled while sending result
@@ -0,0 +1,150 @@
+// Package staleness scores how likely a dependency folder's project is
+// abandoned, from its git history, lockfile, size and neighbouring projects.
+package staleness
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/d4rthvadr/node-cleaner/pkg/models"
+	"github.com/d4rthvadr/node-cleaner/pkg/utils"
+	"github.com/dustin/go-humanize"
+)
+
+// MaxScore is the score of a folder that is abandoned by every measure
+const MaxScore = 100
+
+// Points each signal contributes at most; they add up to MaxScore
+const (
+	commitPoints   = 35 // no commits for a year
+	lockfilePoints = 25 // lockfile unchanged for a year
+	sizePoints     = 15 // 1 GB or more
+	pushedPoints   = 15 // nothing left unpushed
+	siblingPoints  = 10 // neighbouring projects are active while this one is not
+)
+
+const (
+	year = 365 * 24 * time.Hour
+	// a sibling changed this recently counts as active
+	siblingActive = 30 * 24 * time.Hour
+	// a project idle this long next to an active sibling was moved on from
+	siblingIdle = 90 * 24 * time.Hour
+)
+
+// Result is a folder's score and the reasons behind it
+type Result struct {
+	Score   int
+	Reasons []string
+}
+
+// sibling is the most recently active project next to a project
+type sibling struct {
+	name    string
+	changed time.Time
+}
+
+// Scorer computes scores, reading each project's neighbours once.
+// Safe for concurrent use.
+type Scorer struct {
+	now      time.Time
+	mu       sync.Mutex
+	siblings map[string]sibling // by project root
+}
+
+// NewScorer returns a Scorer measuring ages relative to now
+func NewScorer(now time.Time) *Scorer {
+	return &Scorer{
+		now:      now,
+		siblings: make(map[string]sibling),
+	}
+}
+
+// Score rates folder from 0 (in active use) to MaxScore (abandoned).
+// Git signals come from folder.Git, so look that up first.
+func (s *Scorer) Score(folder models.DependencyFolder) Result {
+	var r Result
+	add := func(points int, format string, args ...interface{}) {
+		r.Score += points
+		r.Reasons = append(r.Reasons, fmt.Sprintf("%s (+%d)", fmt.Sprintf(format, args...), points))
+	}
+
+	dir := filepath.Dir(folder.Path)
+	repo := folder.Git
+
+	// project activity: git history, or the folder itself outside git
+	root, lastActivity := dir, folder.ModTime
+	if repo != nil && !repo.LastCommit.IsZero() {
+		root, lastActivity = repo.Root, repo.LastCommit
+		add(scale(s.now.Sub(lastActivity), year, commitPoints), "last commit %s", humanize.Time(lastActivity))
+	} else {
+		add(scale(s.now.Sub(lastActivity), year, commitPoints), "no git history, folder changed %s", humanize.Time(lastActivity))
+	}
+
+	if name, changed, ok := utils.LatestLockfile(dir); ok {
+		add(scale(s.now.Sub(changed), year, lockfilePoints), "%s changed %s", name, humanize.Time(changed))
+	} else {
+		add(0, "no lockfile")
+	}
+
+	add(scale(folder.Size, 1<<30, sizePoints), "%s", humanize.Bytes(uint64(folder.Size)))
+
+	switch {
+	case repo == nil:
+		add(0, "not in a git repository, unpushed work unknown")
+	case repo.Unpushed:
+		add(0, "unpushed work on %s", repo.Branch)
+	default:
+		add(pushedPoints, "no unpushed work")
+	}
+
+	if sib := s.sibling(root); sib.name != "" && s.now.Sub(sib.changed) < siblingActive &&
+		s.now.Sub(lastActivity) >= siblingIdle {
+		add(siblingPoints, "sibling %s changed %s", sib.name, humanize.Time(sib.changed))
+	}
+
+	return r
+}
+
+// scale maps v linearly onto 0..max points, reaching max at full
+func scale[T time.Duration | int64](v, full T, max int) int {
+	if v <= 0 {
+		return 0
+	}
+	if v >= full {
+		return max
+	}
+	return int(float64(v)/float64(full)*float64(max) + 0.5)
+}
+
+// sibling finds the most recently changed directory next to root. A
+// directory's own mtime and its git index count as changes.
+func (s *Scorer) sibling(root string) sibling {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if sib, ok := s.siblings[root]; ok {
+		return sib
+	}
+
+	var newest sibling
+	parent := filepath.Dir(root)
+	entries, _ := os.ReadDir(parent)
+	for _, e := range entries {
+		path := filepath.Join(parent, e.Name())
+		// hidden directories (caches, dotfiles) change all the time
+		if !e.IsDir() || path == root || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		for _, p := range []string{path, filepath.Join(path, ".git", "index")} {
+			if info, err := os.Stat(p); err == nil && info.ModTime().After(newest.changed) {
+				newest = sibling{name: e.Name(), changed: info.ModTime()}
+			}
+		}
+	}
+	s.siblings[root] = newest
+	return newest
+}
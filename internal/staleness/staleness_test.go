@@ -0,0 +1,72 @@
+package staleness
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/d4rthvadr/node-cleaner/pkg/models"
+)
+
+func TestScore(t *testing.T) {
+	now := time.Now()
+	yearAgo := now.AddDate(-1, 0, -1)
+	monthAgo := now.AddDate(0, -1, 0)
+
+	tests := []struct {
+		name      string
+		folderAge time.Time
+		lockAge   time.Time // zero for no lockfile
+		size      int64
+		sibling   time.Time // zero for no sibling
+		want      int
+		reason    string
+	}{
+		{"Fresh project", now, now, 0, time.Time{}, 0, "package-lock.json changed now"},
+		{"No lockfile", now, time.Time{}, 0, time.Time{}, 0, "no lockfile"},
+		{"Abandoned large project", yearAgo, yearAgo, 2 << 30, time.Time{}, commitPoints + lockfilePoints + sizePoints, "package-lock.json changed"},
+		{"Half a size", now, now, 512 << 20, time.Time{}, 8, "537 MB"},
+		{"Moved on to a sibling", yearAgo, time.Time{}, 0, now, commitPoints + siblingPoints, "sibling app-v2 changed"},
+		{"Sibling just as idle", yearAgo, time.Time{}, 0, monthAgo.AddDate(0, -1, 0), commitPoints, "no git history"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parent := t.TempDir()
+			project := filepath.Join(parent, "app")
+			folder := filepath.Join(project, "node_modules")
+			if err := os.MkdirAll(folder, 0755); err != nil {
+				t.Fatal(err)
+			}
+			if !tt.lockAge.IsZero() {
+				lock := filepath.Join(project, "package-lock.json")
+				if err := os.WriteFile(lock, nil, 0644); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.Chtimes(lock, tt.lockAge, tt.lockAge); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if !tt.sibling.IsZero() {
+				sib := filepath.Join(parent, "app-v2")
+				if err := os.Mkdir(sib, 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.Chtimes(sib, tt.sibling, tt.sibling); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got := NewScorer(now).Score(models.DependencyFolder{Path: folder, ModTime: tt.folderAge, Size: tt.size})
+
+			if got.Score != tt.want {
+				t.Errorf("Score = %d; want %d (%s)", got.Score, tt.want, strings.Join(got.Reasons, "; "))
+			}
+			if !strings.Contains(strings.Join(got.Reasons, "; "), tt.reason) {
+				t.Errorf("Reasons = %q; want one mentioning %q", got.Reasons, tt.reason)
+			}
+		})
+	}
+}
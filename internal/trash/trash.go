@@ -0,0 +1,155 @@
+// Package trash moves files into the user's trash following the
+// freedesktop.org Trash specification, so desktop file managers can
+// list and restore them.
+package trash
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/d4rthvadr/node-cleaner/pkg/utils"
+)
+
+// Item describes a folder that was moved to the trash
+type Item struct {
+	OriginalPath string
+	TrashPath    string // location under <trash>/files
+	InfoPath     string // matching <trash>/info/*.trashinfo
+}
+
+// Move moves path into the appropriate trash directory: the home trash when
+// path lives on the same filesystem, otherwise the trash at the top of
+// path's mount point.
+func Move(path string) (*Item, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var st syscall.Stat_t
+	if err := syscall.Lstat(abs, &st); err != nil {
+		return nil, &os.PathError{Op: "lstat", Path: abs, Err: err}
+	}
+
+	trashDir, topDir, err := trashDirFor(abs, uint64(st.Dev))
+	if err != nil {
+		return nil, err
+	}
+
+	// trashes at the top of a mount store paths relative to it
+	infoPath := abs
+	if topDir != "" {
+		if rel, err := filepath.Rel(topDir, abs); err == nil {
+			infoPath = rel
+		}
+	}
+
+	return moveInto(trashDir, abs, infoPath, time.Now())
+}
+
+// homeTrash returns $XDG_DATA_HOME/Trash, defaulting to ~/.local/share/Trash
+func homeTrash() (string, error) {
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dataHome, "Trash"), nil
+}
+
+// trashDirFor picks the trash directory for a file on device dev.
+// topDir is empty for the home trash.
+func trashDirFor(abs string, dev uint64) (trashDir, topDir string, err error) {
+	home, err := homeTrash()
+	if err != nil {
+		return "", "", err
+	}
+	if err := os.MkdirAll(home, 0700); err != nil {
+		return "", "", fmt.Errorf("creating home trash: %w", err)
+	}
+	if utils.DeviceOf(home) == dev {
+		return home, "", nil
+	}
+
+	topDir = utils.MountTop(abs, dev)
+	uid := strconv.Itoa(os.Getuid())
+
+	// $topdir/.Trash/$uid is only valid if .Trash is a real, sticky directory
+	shared := filepath.Join(topDir, ".Trash")
+	if fi, err := os.Lstat(shared); err == nil && fi.IsDir() && fi.Mode()&os.ModeSticky != 0 {
+		dir := filepath.Join(shared, uid)
+		if err := os.MkdirAll(dir, 0700); err == nil {
+			return dir, topDir, nil
+		}
+	}
+
+	dir := filepath.Join(topDir, ".Trash-"+uid)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", "", fmt.Errorf("creating trash on %s: %w", topDir, err)
+	}
+	return dir, topDir, nil
+}
+
+// moveInto reserves a unique name by creating the .trashinfo file
+// exclusively, then renames the folder into files/
+func moveInto(trashDir, abs, infoPath string, deletedAt time.Time) (*Item, error) {
+	filesDir := filepath.Join(trashDir, "files")
+	infoDir := filepath.Join(trashDir, "info")
+	for _, dir := range []string{filesDir, infoDir} {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return nil, err
+		}
+	}
+
+	info := fmt.Sprintf("[Trash Info]\nPath=%s\nDeletionDate=%s\n",
+		(&url.URL{Path: infoPath}).EscapedPath(),
+		deletedAt.Format("2006-01-02T15:04:05"))
+
+	base := filepath.Base(abs)
+	for i := 1; ; i++ {
+		name := base
+		if i > 1 {
+			name = fmt.Sprintf("%s.%d", base, i)
+		}
+
+		infoFile := filepath.Join(infoDir, name+".trashinfo")
+		f, err := os.OpenFile(infoFile, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if errors.Is(err, os.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = f.WriteString(info)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			os.Remove(infoFile)
+			return nil, err
+		}
+
+		dest := filepath.Join(filesDir, name)
+		if _, err := os.Lstat(dest); err == nil {
+			// orphaned entry without info file, keep looking
+			os.Remove(infoFile)
+			continue
+		}
+		if err := os.Rename(abs, dest); err != nil {
+			os.Remove(infoFile)
+			return nil, err
+		}
+
+		return &Item{OriginalPath: abs, TrashPath: dest, InfoPath: infoFile}, nil
+	}
+}
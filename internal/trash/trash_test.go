@@ -0,0 +1,72 @@
+package trash
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMoveToHomeTrash(t *testing.T) {
+	dataHome := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", dataHome)
+
+	project := filepath.Join(t.TempDir(), "my app")
+	folder := filepath.Join(project, "node_modules")
+	if err := os.MkdirAll(folder, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	item, err := Move(folder)
+	if err != nil {
+		t.Fatalf("Move() error = %v", err)
+	}
+
+	// t.TempDir may live on another filesystem than XDG_DATA_HOME
+	if !strings.HasPrefix(item.TrashPath, filepath.Join(dataHome, "Trash")) {
+		t.Skipf("temp dirs on different devices, trashed to %s", item.TrashPath)
+	}
+
+	if _, err := os.Stat(folder); !os.IsNotExist(err) {
+		t.Errorf("original still exists: %v", err)
+	}
+	if _, err := os.Stat(item.TrashPath); err != nil {
+		t.Errorf("trashed folder missing: %v", err)
+	}
+
+	info, err := os.ReadFile(item.InfoPath)
+	if err != nil {
+		t.Fatalf("reading trashinfo: %v", err)
+	}
+	if !strings.Contains(string(info), "Path="+strings.ReplaceAll(folder, " ", "%20")+"\n") {
+		t.Errorf("trashinfo has wrong Path:\n%s", info)
+	}
+}
+
+func TestMoveIntoAvoidsCollisions(t *testing.T) {
+	trashDir := t.TempDir()
+
+	var items []*Item
+	for i := 0; i < 3; i++ {
+		folder := filepath.Join(t.TempDir(), "target")
+		if err := os.Mkdir(folder, 0755); err != nil {
+			t.Fatal(err)
+		}
+		item, err := moveInto(trashDir, folder, folder, time.Now())
+		if err != nil {
+			t.Fatalf("moveInto() error = %v", err)
+		}
+		items = append(items, item)
+	}
+
+	want := []string{"target", "target.2", "target.3"}
+	for i, item := range items {
+		if got := filepath.Base(item.TrashPath); got != want[i] {
+			t.Errorf("item %d trashed as %q; want %q", i, got, want[i])
+		}
+		if got := filepath.Base(item.InfoPath); got != want[i]+".trashinfo" {
+			t.Errorf("item %d info file %q; want %q", i, got, want[i]+".trashinfo")
+		}
+	}
+}
@@ -3,10 +3,18 @@ package ui
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 	"text/tabwriter"
+	"time"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/d4rthvadr/node-cleaner/internal/audit"
+	"github.com/d4rthvadr/node-cleaner/internal/goal"
+	"github.com/d4rthvadr/node-cleaner/internal/policy"
+	"github.com/d4rthvadr/node-cleaner/internal/project"
+	"github.com/d4rthvadr/node-cleaner/internal/quarantine"
+	"github.com/d4rthvadr/node-cleaner/internal/restore"
 	"github.com/d4rthvadr/node-cleaner/pkg/models"
 	"github.com/dustin/go-humanize"
 )
@@ -23,7 +31,9 @@ var (
 			Foreground(lipgloss.Color("11"))
 )
 
-func DisplayScanResults(result *models.ScanResult) {
+// DisplayScanResults prints the scanned folders; with explain set each
+// folder is followed by the reasons behind its staleness score
+func DisplayScanResults(result *models.ScanResult, explain bool) {
 
 	fmt.Println(headerStyle.Render("Scan Results:"))
 	fmt.Println(strings.Repeat("-", 80))
@@ -32,7 +42,9 @@ func DisplayScanResults(result *models.ScanResult) {
 
 	// Colorful header
 	fmt.Fprintln(w, headerStyle.Render("SIZE")+"\t"+
+		headerStyle.Render("SCORE")+"\t"+
 		headerStyle.Render("LAST ACCESSED")+"\t"+
+		headerStyle.Render("GIT")+"\t"+
 		headerStyle.Render("PATH"))
 	fmt.Fprintln(w, strings.Repeat("─", 80))
 
@@ -49,16 +61,39 @@ func DisplayScanResults(result *models.ScanResult) {
 			sizeStr = successStyle.Render(sizeStr) // green for small
 		}
 
-		fmt.Fprintf(w, "%s\t%s\t%s\n",
+		pathStr := pathStyle.Render(folder.Path)
+		if folder.Tracked {
+			pathStr += " " + warningStyle.Render("[tracked]")
+		}
+		if folder.Protected != "" {
+			pathStr += " " + warningStyle.Render("[protected]")
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
 			sizeStr,
+			scoreLabel(folder.Score),
 			humanize.Time(folder.AccessTime),
-			pathStyle.Render(folder.Path),
+			gitLabel(folder.Git),
+			pathStr,
 		)
+		if explain {
+			if folder.Git != nil {
+				repo := "repository " + folder.Git.Root
+				if folder.Git.RemoteURL != "" {
+					repo += " (" + folder.Git.RemoteURL + ")"
+				}
+				fmt.Fprintf(w, "\t\t\t\t  %s\n", repo)
+			}
+			for _, reason := range folder.ScoreReasons {
+				fmt.Fprintf(w, "\t\t\t\t  %s\n", reason)
+			}
+		}
 
 	}
 	w.Flush()
 
 	fmt.Println(strings.Repeat("─", 80))
+	DisplayProjects("📦 Projects:", result.Folders)
 	fmt.Printf("\n%s\n", headerStyle.Render("✨ Summary:"))
 	fmt.Printf(" Total folders: %s\n", successStyle.Render(fmt.Sprintf("%d", result.TotalCount)))
 	fmt.Printf(" Total size: %s\n", errorStyle.Render(humanize.Bytes(uint64(result.TotalSize))))
@@ -84,7 +119,18 @@ func DisplayCleanResults(result *models.CleanResult) {
 	if len(result.DeletedFolders) > 0 {
 		fmt.Println(successStyle.Render("Deleted Folders:"))
 		for _, path := range result.DeletedFolders {
-			fmt.Println(" - " + path)
+			line := " - " + path
+			if id, ok := result.QuarantineIDs[path]; ok {
+				line += " (quarantine id " + id + ")"
+			} else if trashedTo, ok := result.TrashedTo[path]; ok {
+				line += " -> " + trashedTo
+			} else if archivedTo, ok := result.ArchivedTo[path]; ok {
+				line += " (archived to " + archivedTo + ")"
+			}
+			if d, ok := result.FolderDurations[path]; ok {
+				line += fmt.Sprintf(" [%s]", d.Round(time.Millisecond))
+			}
+			fmt.Println(line)
 		}
 	}
 
@@ -94,12 +140,345 @@ func DisplayCleanResults(result *models.CleanResult) {
 			fmt.Printf(" - %s: %s\n", fail.Path, fail.Reason)
 		}
 	}
+
+	if len(result.Warnings) > 0 {
+		fmt.Printf("\n%s\n", warningStyle.Render("Warnings:"))
+		for _, warning := range result.Warnings {
+			fmt.Printf(" - %s\n", warning)
+		}
+	}
 	fmt.Println(strings.Repeat("─", 80))
 	fmt.Printf("\nTotal space reclaimed: %s\n", successStyle.Render(humanize.Bytes(uint64(result.SpaceReclaimed))))
+	if result.SpaceMoved > 0 {
+		// trashed, quarantined or still being deleted in the background
+		fmt.Printf(" Moved, still on disk: %s\n", warningStyle.Render(humanize.Bytes(uint64(result.SpaceMoved))))
+	}
+	if len(result.ArchivedTo) > 0 {
+		fmt.Printf(" Archived: %s compressed for %s reclaimed\n",
+			warningStyle.Render(humanize.Bytes(uint64(result.ArchivedSize))),
+			humanize.Bytes(uint64(result.SpaceReclaimed)))
+	}
 	fmt.Printf(" Duration: %s\n", result.Duration)
 
+	if len(result.QuarantineIDs) > 0 {
+		fmt.Println(" Undo with: depo-cleaner restore <id|path>")
+	}
+	if len(result.ArchivedTo) > 0 {
+		fmt.Println(" Undo with: depo-cleaner restore --from-archive <dir> <path>")
+	}
+	if len(result.Tombstones) > 0 {
+		fmt.Printf(" %d folders are being deleted in the background (depo-cleaner reap finishes them)\n", len(result.Tombstones))
+	}
+	if !result.DryRun && len(result.DeletedFolders) > 0 {
+		fmt.Println(" Reinstall with: depo-cleaner restore-hint <project>")
+	}
+
 	if !result.DryRun {
 		fmt.Printf("\n%s\n", successStyle.Render("✓ Cleanup complete!"))
 	}
 
 }
+
+// NewCleanProgress returns a progress callback that prints one line when a
+// folder starts, finishes or fails, and at most one line per second while
+// a large folder is being removed
+func NewCleanProgress() func(event models.CleanEvent) {
+	lastReport := make(map[string]time.Time)
+
+	return func(event models.CleanEvent) {
+		switch event.Kind {
+		case models.CleanStarted:
+			fmt.Printf("  … %s (%s)\n", event.Path, humanize.Bytes(uint64(event.Size)))
+			lastReport[event.Path] = time.Now()
+		case models.CleanProgress:
+			if time.Since(lastReport[event.Path]) < time.Second {
+				return
+			}
+			lastReport[event.Path] = time.Now()
+			fmt.Printf("    %s: %s of %s removed\n", event.Path,
+				humanize.Bytes(uint64(event.BytesRemoved)), humanize.Bytes(uint64(event.Size)))
+		case models.CleanFinished:
+			delete(lastReport, event.Path)
+			fmt.Printf("  %s %s in %s\n", successStyle.Render("✓"), event.Path, event.Duration.Round(time.Millisecond))
+		case models.CleanFailed:
+			delete(lastReport, event.Path)
+			fmt.Printf("  %s %s: %v\n", errorStyle.Render("✗"), event.Path, event.Err)
+		}
+	}
+}
+
+func DisplayQuarantine(entries []quarantine.Entry) {
+
+	fmt.Println(headerStyle.Render("Quarantined Folders:"))
+	fmt.Println(strings.Repeat("-", 80))
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(w, headerStyle.Render("ID")+"\t"+
+		headerStyle.Render("SIZE")+"\t"+
+		headerStyle.Render("QUARANTINED")+"\t"+
+		headerStyle.Render("ORIGINAL PATH"))
+
+	var total int64
+	for _, entry := range entries {
+		total += entry.Size
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
+			entry.ID,
+			humanize.Bytes(uint64(entry.Size)),
+			humanize.Time(entry.QuarantinedAt),
+			entry.OriginalPath,
+		)
+	}
+	w.Flush()
+
+	fmt.Println(strings.Repeat("─", 80))
+	fmt.Printf(" Total held: %s in %d folders\n", warningStyle.Render(humanize.Bytes(uint64(total))), len(entries))
+}
+
+// DisplayRestoreHints prints how to reinstall each cleaned project, with a
+// warning when its lockfile changed since the clean
+func DisplayRestoreHints(hints []restore.Hint) {
+	for i, h := range hints {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("%s (%s, %s), cleaned %s\n", headerStyle.Render(h.Name), h.Ecosystem, h.PackageManager,
+			humanize.Time(h.CleanedAt))
+		for _, folder := range h.Folders {
+			fmt.Printf(" - %s\n", folder)
+		}
+		if h.Lockfile != "" {
+			fmt.Printf(" Lockfile: %s (sha256 %.12s)\n", h.Lockfile, h.LockfileHash)
+		}
+		fmt.Printf(" Reinstall: %s\n", successStyle.Render("cd "+h.Project+" && "+h.Command))
+
+		if h.LockfileChanged() {
+			fmt.Printf(" %s\n", warningStyle.Render("⚠ "+h.Lockfile+
+				" changed since the clean, the reinstall will not restore the same dependencies"))
+		}
+	}
+}
+
+func DisplayHistory(records []audit.Record) {
+
+	fmt.Println(headerStyle.Render("Clean History:"))
+	fmt.Println(strings.Repeat("-", 80))
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(w, headerStyle.Render("DATE")+"\t"+
+		headerStyle.Render("RESULT")+"\t"+
+		headerStyle.Render("SIZE")+"\t"+
+		headerStyle.Render("TYPE")+"\t"+
+		headerStyle.Render("PATH"))
+
+	var reclaimed, moved int64
+	runs := make(map[string]bool)
+
+	for _, r := range records {
+		runs[r.RunID] = true
+
+		switch {
+		case r.Reclaimed():
+			reclaimed += r.Size
+		case r.Moved():
+			moved += r.Size
+		}
+
+		outcome := r.Result
+		switch {
+		case r.DryRun:
+			outcome = warningStyle.Render(outcome + " (dry run)")
+		case r.Result == audit.ResultFailed:
+			outcome = errorStyle.Render(outcome)
+		default:
+			outcome = successStyle.Render(outcome)
+		}
+
+		path := r.Path
+		if r.Error != "" {
+			path += " (" + r.Error + ")"
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+			r.Timestamp.Local().Format("2006-01-02 15:04"),
+			outcome,
+			humanize.Bytes(uint64(r.Size)),
+			r.Ecosystem,
+			path,
+		)
+	}
+	w.Flush()
+
+	fmt.Println(strings.Repeat("─", 80))
+	fmt.Printf(" Runs: %d, folders: %d\n", len(runs), len(records))
+	fmt.Printf(" Total space reclaimed: %s\n", successStyle.Render(humanize.Bytes(uint64(reclaimed))))
+	if moved > 0 {
+		fmt.Printf(" Moved to trash or quarantine: %s (still on disk until emptied or purged)\n",
+			humanize.Bytes(uint64(moved)))
+	}
+}
+
+func DisplayPolicyCheck(folders []models.DependencyFolder, decisions []policy.Decision) {
+
+	fmt.Println(headerStyle.Render("Policy Check:"))
+	fmt.Println(strings.Repeat("-", 80))
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(w, headerStyle.Render("ACTION")+"\t"+
+		headerStyle.Render("RULE")+"\t"+
+		headerStyle.Render("SIZE")+"\t"+
+		headerStyle.Render("PATH"))
+
+	counts := make(map[policy.Action]int)
+	sizes := make(map[policy.Action]int64)
+
+	for i, folder := range folders {
+		decision := decisions[i]
+		counts[decision.Action]++
+		sizes[decision.Action] += folder.Size
+
+		action, rule := string(decision.Action), decision.Rule
+		switch decision.Action {
+		case policy.ActionNone:
+			action, rule = "-", "no rule"
+		case policy.ActionProtect:
+			action = successStyle.Render(action)
+		case policy.ActionAutoClean, policy.ActionTrash:
+			action = errorStyle.Render(action)
+		case policy.ActionSuggest:
+			action = warningStyle.Render(action)
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
+			action,
+			rule,
+			humanize.Bytes(uint64(folder.Size)),
+			folder.Path,
+		)
+	}
+	w.Flush()
+
+	fmt.Println(strings.Repeat("─", 80))
+	for _, action := range []policy.Action{policy.ActionAutoClean, policy.ActionTrash, policy.ActionSuggest, policy.ActionProtect} {
+		if counts[action] > 0 {
+			fmt.Printf(" %s: %d folders, %s\n", action, counts[action], humanize.Bytes(uint64(sizes[action])))
+		}
+	}
+	if counts[policy.ActionNone] > 0 {
+		fmt.Printf(" no rule: %d folders\n", counts[policy.ActionNone])
+	}
+}
+
+// gitLabel summarizes the folder's repository: the branch, marked with *
+// when there are uncommitted changes and ↑ when there are unpushed
+// commits, and when it was last committed to
+func gitLabel(info *models.GitInfo) string {
+	if info == nil {
+		return "-"
+	}
+
+	branch := info.Branch
+	if branch == "" {
+		branch = "(detached)"
+	}
+	if info.Dirty {
+		branch += "*"
+	}
+	if info.Unpushed {
+		branch += "↑"
+	}
+	if info.Dirty || info.Unpushed {
+		branch = warningStyle.Render(branch)
+	}
+
+	if info.LastCommit.IsZero() {
+		return branch
+	}
+	return branch + " " + humanize.Time(info.LastCommit)
+}
+
+// scoreLabel colors a staleness score: red for likely abandoned,
+// green for in active use
+func scoreLabel(score int) string {
+	label := fmt.Sprintf("%d", score)
+	switch {
+	case score >= 70:
+		return errorStyle.Render(label)
+	case score >= 40:
+		return warningStyle.Render(label)
+	default:
+		return successStyle.Render(label)
+	}
+}
+
+// DisplayGoalSelection shows the folders chosen to meet a free space goal
+// and the projected free space once they are deleted
+func DisplayGoalSelection(sel *goal.Selection) {
+	disk := sel.Goal.Disk
+
+	fmt.Println(headerStyle.Render("Free Space Goal:"))
+	fmt.Printf(" Goal: %s on %s\n", sel.Goal.Target, disk.Path)
+	fmt.Printf(" Now: %s free of %s (%.1f%%)\n",
+		humanize.Bytes(uint64(disk.Free)), humanize.Bytes(uint64(disk.Total)), disk.PercentFree(0))
+
+	if len(sel.Chosen) == 0 {
+		return
+	}
+
+	fmt.Println(strings.Repeat("-", 80))
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, headerStyle.Render("RECLAIMS")+"\t"+
+		headerStyle.Render("SIZE")+"\t"+
+		headerStyle.Render("LAST CHANGED")+"\t"+
+		headerStyle.Render("PATH"))
+	for _, c := range sel.Chosen {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
+			errorStyle.Render(humanize.Bytes(uint64(c.Reclaimable))),
+			humanize.Bytes(uint64(c.Folder.Size)),
+			humanize.Time(c.Folder.ModTime),
+			c.Folder.Path,
+		)
+	}
+	w.Flush()
+	fmt.Println(strings.Repeat("─", 80))
+
+	projected := fmt.Sprintf("%s free (%.1f%%)",
+		humanize.Bytes(uint64(disk.Free+sel.Reclaimable)), disk.PercentFree(sel.Reclaimable))
+	if sel.Met() {
+		projected = successStyle.Render(projected)
+	} else {
+		projected = warningStyle.Render(projected)
+	}
+	fmt.Printf(" Projected: %s after reclaiming %s from %d folders\n",
+		projected, humanize.Bytes(uint64(sel.Reclaimable)), len(sel.Chosen))
+	if !sel.Met() {
+		fmt.Printf(" %s\n", warningStyle.Render(fmt.Sprintf("Falls short of the goal by %s",
+			humanize.Bytes(uint64(sel.Goal.Need-sel.Reclaimable)))))
+	}
+}
+
+// DisplayProjects prints folders grouped by the project they belong to,
+// one line per project: "my-app (node, pnpm): node_modules 640 MB"
+func DisplayProjects(title string, folders []models.DependencyFolder) {
+	groups := project.GroupFolders(folders)
+	if len(groups) == 0 {
+		return
+	}
+
+	fmt.Printf("\n%s\n", headerStyle.Render(title))
+	for _, g := range groups {
+		parts := make([]string, len(g.Folders))
+		for i, f := range g.Folders {
+			name := f.Path
+			if root := g.Root(); root != "" {
+				if rel, err := filepath.Rel(root, f.Path); err == nil {
+					name = rel
+				}
+			}
+			parts[i] = name + " " + humanize.Bytes(uint64(f.Size))
+		}
+		fmt.Printf(" %s: %s\n", successStyle.Render(g.Label()), strings.Join(parts, ", "))
+	}
+}
@@ -1,6 +1,9 @@
 package ui
 
 import (
+	"strconv"
+	"strings"
+
 	"github.com/charmbracelet/bubbles/table"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/d4rthvadr/node-cleaner/pkg/models"
@@ -13,6 +16,7 @@ type SelectionModel struct {
 	selected      map[int]bool
 	totalSelected int64
 	totalSize     int64
+	sortKey       string // current order, "" for scan order
 }
 
 func NewSelectionModel(folders []models.DependencyFolder) *SelectionModel {
@@ -20,6 +24,7 @@ func NewSelectionModel(folders []models.DependencyFolder) *SelectionModel {
 	columns := []table.Column{
 		{Title: "Select", Width: 8},
 		{Title: "Size", Width: 12},
+		{Title: "Score", Width: 6},
 		{Title: "Last Accessed", Width: 20},
 		{Title: "Path", Width: 50},
 	}
@@ -28,10 +33,11 @@ func NewSelectionModel(folders []models.DependencyFolder) *SelectionModel {
 
 	for i, folder := range folders {
 		rows[i] = table.Row{
-			"[ ]",
+			checkbox(folder, false),
 			humanize.Bytes(uint64(folder.Size)),
+			strconv.Itoa(folder.Score),
 			humanize.Time(folder.AccessTime),
-			folder.Path,
+			pathLabel(folder),
 		}
 	}
 
@@ -61,18 +67,27 @@ func (m *SelectionModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, tea.Quit
 		case " ":
 			idx := m.table.Cursor()
-			m.selected[idx] = !m.selected[idx]
-
-			// Update total
-			if m.selected[idx] {
-				m.totalSelected += m.folders[idx].Size
-			} else {
-				m.totalSelected -= m.folders[idx].Size
+			if m.folders[idx].Protected != "" {
+				// protected by a project marker, never selectable
+				return m, nil
+			}
+			m.setSelected(idx, !m.selected[idx])
+
+			// workspace members share one install and are selected together
+			if ws := m.folders[idx].Workspace; ws != nil {
+				for i, f := range m.folders {
+					if f.Workspace != nil && f.Workspace.Root == ws.Root && f.Workspace.Kind == ws.Kind && f.Protected == "" {
+						m.setSelected(i, m.selected[idx])
+					}
+				}
 			}
 
 			// Update row
 			m.updateRow(idx)
 			return m, nil
+		case "s":
+			m.cycleSort()
+			return m, nil
 		case "enter":
 			return m, tea.Quit
 		}
@@ -94,7 +109,13 @@ func (m SelectionModel) View() string {
 	footer := "\n"
 	footer += "Selected: " + humanize.Bytes(uint64(m.totalSelected))
 	footer += " (" + humanize.Comma(int64(selectedCount)) + " folders)\n"
-	footer += "\nControls: [Space] Toggle  [Enter] Confirm  [q/Esc] Cancel\n"
+	if m.sortKey != "" {
+		footer += "Sorted by " + m.sortKey + "\n"
+	}
+	if idx := m.table.Cursor(); idx >= 0 && idx < len(m.folders) && len(m.folders[idx].ScoreReasons) > 0 {
+		footer += "Score " + strconv.Itoa(m.folders[idx].Score) + ": " + strings.Join(m.folders[idx].ScoreReasons, "; ") + "\n"
+	}
+	footer += "\nControls: [Space] Toggle  [s] Sort  [Enter] Confirm  [q/Esc] Cancel\n"
 	
 	return m.table.View() + footer
 }
@@ -105,15 +126,12 @@ func (m *SelectionModel) updateRow(idx int) {
 	
 	// Rebuild all rows with updated selection states
 	for i, folder := range m.folders {
-		checkmark := "[ ]"
-		if m.selected[i] {
-			checkmark = "[x]"
-		}
 		rows[i] = table.Row{
-			checkmark,
+			checkbox(folder, m.selected[i]),
 			humanize.Bytes(uint64(folder.Size)),
+			strconv.Itoa(folder.Score),
 			humanize.Time(folder.AccessTime),
-			folder.Path,
+			pathLabel(folder),
 		}
 	}
 	
@@ -121,6 +139,76 @@ func (m *SelectionModel) updateRow(idx int) {
 	m.table.SetRows(rows)
 }
 
+// setSelected marks the folder at idx and keeps the selected total
+func (m *SelectionModel) setSelected(idx int, selected bool) {
+	if m.selected[idx] == selected {
+		return
+	}
+	m.selected[idx] = selected
+	if selected {
+		m.totalSelected += m.folders[idx].Size
+	} else {
+		m.totalSelected -= m.folders[idx].Size
+	}
+}
+
+// cycleSort moves to the next sort order, keeping the selection
+func (m *SelectionModel) cycleSort() {
+	next := SortKeys[0]
+	for i, key := range SortKeys {
+		if key == m.sortKey && i+1 < len(SortKeys) {
+			next = SortKeys[i+1]
+		}
+	}
+
+	selected := make(map[string]bool)
+	for idx, isSelected := range m.selected {
+		if isSelected {
+			selected[m.folders[idx].Path] = true
+		}
+	}
+
+	if err := SortFolders(m.folders, next); err != nil {
+		return
+	}
+	m.sortKey = next
+
+	m.selected = make(map[int]bool)
+	for i, folder := range m.folders {
+		if selected[folder.Path] {
+			m.selected[i] = true
+		}
+	}
+	m.updateRow(m.table.Cursor())
+}
+
+// pathLabel renders the path column with badges for folders that need care
+func pathLabel(folder models.DependencyFolder) string {
+	label := folder.Path
+	if folder.Tracked {
+		label += " [tracked]"
+	}
+	if folder.Workspace != nil {
+		label += " [workspace: " + folder.Workspace.Name + "]"
+	}
+	if folder.Protected != "" {
+		label += " [protected: " + folder.Protected + "]"
+	}
+	return label
+}
+
+// checkbox renders the select column; protected folders can't be ticked
+func checkbox(folder models.DependencyFolder, selected bool) string {
+	switch {
+	case folder.Protected != "":
+		return "[-]"
+	case selected:
+		return "[x]"
+	default:
+		return "[ ]"
+	}
+}
+
 func (m *SelectionModel) GetSelectedFolders() []models.DependencyFolder {
 	var selected []models.DependencyFolder
 	for idx, isSelected := range m.selected {
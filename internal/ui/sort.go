@@ -0,0 +1,33 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/d4rthvadr/node-cleaner/pkg/models"
+)
+
+// SortKeys are the orders accepted by SortFolders
+var SortKeys = []string{"score", "size", "age", "path"}
+
+// SortFolders orders folders by key: highest staleness score or size
+// first, least recently changed first, or by path
+func SortFolders(folders []models.DependencyFolder, key string) error {
+	var less func(a, b models.DependencyFolder) bool
+	switch key {
+	case "score":
+		less = func(a, b models.DependencyFolder) bool { return a.Score > b.Score }
+	case "size":
+		less = func(a, b models.DependencyFolder) bool { return a.Size > b.Size }
+	case "age":
+		less = func(a, b models.DependencyFolder) bool { return a.ModTime.Before(b.ModTime) }
+	case "path":
+		less = func(a, b models.DependencyFolder) bool { return a.Path < b.Path }
+	default:
+		return fmt.Errorf("unknown sort order %q (want %s)", key, strings.Join(SortKeys, ", "))
+	}
+
+	sort.SliceStable(folders, func(i, j int) bool { return less(folders[i], folders[j]) })
+	return nil
+}
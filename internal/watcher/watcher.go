@@ -0,0 +1,302 @@
+package watcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/d4rthvadr/node-cleaner/internal/analyzer"
+	"github.com/d4rthvadr/node-cleaner/internal/cache"
+	"github.com/d4rthvadr/node-cleaner/internal/logger"
+	"github.com/d4rthvadr/node-cleaner/pkg/models"
+	"github.com/d4rthvadr/node-cleaner/pkg/utils"
+	"github.com/fsnotify/fsnotify"
+)
+
+// Watcher keeps filesystem watches on known dependency folders and their
+// project roots, and keeps the cache in sync as they change so the next
+// scan can be served almost entirely from cache.
+type Watcher struct {
+	cache    cache.Backend
+	analyzer *analyzer.Analyzer
+	fsw      *fsnotify.Watcher
+
+	debounce     time.Duration
+	pollInterval time.Duration
+
+	tracked map[string]bool      // dependency folders we know about
+	watched map[string]bool      // directories with an active watch
+	polled  map[string]time.Time // folders we could not watch, by last seen mod time
+	dirty   map[string]time.Time // folders waiting to be refreshed, by first change
+
+	limitHit bool
+	logger   logger.Logger
+}
+
+// NewWatcher creates a Watcher backed by the given cache.
+// debounce delays refreshes so bursts (e.g. npm install) are handled once;
+// pollInterval is used for folders that could not get a watch, and for
+// re-reading the cache to follow folders that later scans added.
+func NewWatcher(c cache.Backend, debounce, pollInterval time.Duration) (*Watcher, error) {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("creating watcher: %w", err)
+	}
+
+	return &Watcher{
+		cache:        c,
+		analyzer:     analyzer.NewAnalyzer(),
+		fsw:          fsw,
+		debounce:     debounce,
+		pollInterval: pollInterval,
+		tracked:      make(map[string]bool),
+		watched:      make(map[string]bool),
+		polled:       make(map[string]time.Time),
+		dirty:        make(map[string]time.Time),
+		logger:       logger.Discard(),
+	}, nil
+}
+
+// SetLogger sets the logger that receives changes and watch errors
+func (w *Watcher) SetLogger(l logger.Logger) {
+	w.logger = l
+	w.analyzer.SetLogger(l)
+}
+
+// Run watches every folder currently in the cache until ctx is cancelled
+func (w *Watcher) Run(ctx context.Context) error {
+	defer w.fsw.Close()
+
+	for path, entry := range w.cache.Entries() {
+		w.track(path, entry.ModTime)
+	}
+
+	fmt.Printf("Watching %d dependency folders (%d watches", len(w.tracked), len(w.watched))
+	if len(w.polled) > 0 {
+		fmt.Printf(", %d polled every %s", len(w.polled), w.pollInterval)
+	}
+	fmt.Println(")")
+	w.logger.Info("watch started", "folders", len(w.tracked), "watches", len(w.watched), "polled", len(w.polled))
+
+	flushTicker := time.NewTicker(time.Second)
+	defer flushTicker.Stop()
+
+	pollTicker := time.NewTicker(w.pollInterval)
+	defer pollTicker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			w.flush(time.Now().Add(w.debounce)) // refresh everything still pending
+			return w.cache.Save()
+
+		case event, ok := <-w.fsw.Events:
+			if !ok {
+				return nil
+			}
+			w.handleEvent(event)
+
+		case err, ok := <-w.fsw.Errors:
+			if !ok {
+				return nil
+			}
+			if errors.Is(err, fsnotify.ErrEventOverflow) {
+				// events were dropped, we can't tell what changed
+				w.logger.Warn("watch event queue overflowed, rechecking all folders")
+				for path := range w.tracked {
+					w.markDirty(path)
+				}
+				continue
+			}
+			w.logger.Warn("watch error", "error", err)
+
+		case now := <-flushTicker.C:
+			w.flush(now)
+
+		case <-pollTicker.C:
+			w.reload()
+			w.poll()
+		}
+	}
+}
+
+// track starts following a dependency folder: its project root gets a watch
+// so the folder being removed or recreated is seen, and the folder itself gets
+// one so top-level changes (installs, removals) are seen.
+func (w *Watcher) track(path string, modTime time.Time) {
+	w.tracked[path] = true
+
+	okRoot := w.addWatch(filepath.Dir(path))
+	okSelf := w.addWatch(path)
+
+	if !okRoot || !okSelf {
+		w.polled[path] = modTime
+	}
+}
+
+// untrack stops following a dependency folder that no longer exists
+func (w *Watcher) untrack(path string) {
+	delete(w.tracked, path)
+	delete(w.polled, path)
+	delete(w.dirty, path)
+
+	if w.watched[path] {
+		w.fsw.Remove(path)
+		delete(w.watched, path)
+	}
+}
+
+// addWatch adds a watch on dir and reports whether it is being watched.
+// Once the kernel watch limit is hit no further watches are attempted
+// and the caller falls back to polling.
+func (w *Watcher) addWatch(dir string) bool {
+	if w.watched[dir] {
+		return true
+	}
+	if w.limitHit {
+		return false
+	}
+
+	err := w.fsw.Add(dir)
+	if err == nil {
+		w.watched[dir] = true
+		return true
+	}
+
+	if isWatchLimit(err) {
+		w.limitHit = true
+		w.logger.Warn("watch limit reached, falling back to polling (raise fs.inotify.max_user_watches to watch more)",
+			"watches", len(w.watched), "poll_interval", w.pollInterval)
+		return false
+	}
+
+	if !os.IsNotExist(err) {
+		w.logger.Warn("cannot watch directory", "path", dir, "error", err)
+	}
+	return false
+}
+
+// isWatchLimit reports whether err means the per-user watch limit
+// (inotify max_user_watches) or file descriptor limit (kqueue) was reached
+func isWatchLimit(err error) bool {
+	return errors.Is(err, syscall.ENOSPC) || errors.Is(err, syscall.EMFILE)
+}
+
+// handleEvent maps a filesystem event to the dependency folder it affects
+func (w *Watcher) handleEvent(event fsnotify.Event) {
+	path := event.Name
+
+	switch {
+	case w.tracked[path]:
+		// the dependency folder itself was created, removed or renamed
+		w.markDirty(path)
+
+	case utils.IsTargetDirectory(filepath.Base(path)) && w.watched[filepath.Dir(path)]:
+		// a new dependency folder appeared in a watched project root
+		if event.Has(fsnotify.Create) {
+			w.markDirty(path)
+		}
+
+	case w.tracked[filepath.Dir(path)]:
+		// something changed at the top level of a dependency folder
+		w.markDirty(filepath.Dir(path))
+	}
+
+	if event.Has(fsnotify.Remove) || event.Has(fsnotify.Rename) {
+		// the kernel drops watches on removed directories
+		delete(w.watched, path)
+	}
+}
+
+func (w *Watcher) markDirty(path string) {
+	if _, exists := w.dirty[path]; !exists {
+		w.dirty[path] = time.Now()
+	}
+}
+
+// flush refreshes every dirty folder whose changes have settled
+func (w *Watcher) flush(now time.Time) {
+	refreshed := 0
+
+	for path, since := range w.dirty {
+		if now.Sub(since) < w.debounce {
+			continue
+		}
+		delete(w.dirty, path)
+		w.refresh(path)
+		refreshed++
+	}
+
+	if refreshed == 0 {
+		return
+	}
+	if err := w.cache.Save(); err != nil {
+		w.logger.Error("failed to save cache", "error", err)
+	}
+}
+
+// refresh re-analyzes a folder and updates its cache entry,
+// or drops the entry if the folder is gone
+func (w *Watcher) refresh(path string) {
+	info, err := os.Stat(path)
+	if err != nil || !info.IsDir() {
+		w.cache.Delete(path)
+		w.untrack(path)
+		w.logger.Info("dependency folder removed", "path", path)
+		return
+	}
+
+	folder, err := w.analyzer.Analyze(path)
+	if err != nil {
+		w.logger.Warn("analyzing folder failed", "path", path, "error", err)
+		return
+	}
+
+	w.cache.Set(path, &models.CacheEntry{
+		Path:     path,
+		Size:     folder.Size,
+		ModTime:  folder.ModTime,
+		LastScan: time.Now(),
+	})
+
+	if !w.tracked[path] {
+		w.track(path, folder.ModTime)
+	} else if _, polled := w.polled[path]; polled {
+		w.polled[path] = folder.ModTime
+	}
+	w.logger.Info("dependency folder updated", "path", path, "size", folder.Size)
+}
+
+// reload re-reads the cache and starts following folders that were added
+// to it since, e.g. by a scan of a new project
+func (w *Watcher) reload() {
+	if err := w.cache.Reload(); err != nil {
+		w.logger.Warn("re-reading cache failed", "error", err)
+		return
+	}
+
+	added := 0
+	for path, entry := range w.cache.Entries() {
+		if !w.tracked[path] {
+			w.track(path, entry.ModTime)
+			added++
+		}
+	}
+	if added > 0 {
+		w.logger.Info("following folders added to the cache", "folders", added)
+	}
+}
+
+// poll checks folders without a watch for mod time changes
+func (w *Watcher) poll() {
+	for path, lastModTime := range w.polled {
+		info, err := os.Stat(path)
+		if err != nil || !info.ModTime().Equal(lastModTime) {
+			w.markDirty(path)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package watcher
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/d4rthvadr/node-cleaner/internal/cache"
+	"github.com/d4rthvadr/node-cleaner/pkg/models"
+)
+
+// waitFor polls cond until it holds or the deadline passes
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Fatal("condition not met before deadline")
+}
+
+func TestWatcherTracksCreateAndRemove(t *testing.T) {
+	dir := t.TempDir()
+	project := filepath.Join(dir, "app")
+	existing := filepath.Join(project, "node_modules")
+	if err := os.MkdirAll(existing, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := cache.NewCache(filepath.Join(dir, "cache.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, _ := os.Stat(existing)
+	c.Set(existing, &models.CacheEntry{Path: existing, ModTime: info.ModTime()})
+
+	w, err := NewWatcher(c, 100*time.Millisecond, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- w.Run(ctx) }()
+
+	// give Run a moment to register its watches
+	time.Sleep(200 * time.Millisecond)
+
+	venv := filepath.Join(project, ".venv")
+	if err := os.Mkdir(venv, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.RemoveAll(existing); err != nil {
+		t.Fatal(err)
+	}
+
+	waitFor(t, func() bool {
+		_, removed := c.Get(existing)
+		_, added := c.Get(venv)
+		return !removed && added
+	})
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Run() error = %v", err)
+	}
+}
+
+func TestWatcherFollowsFoldersAddedToCache(t *testing.T) {
+	dir := t.TempDir()
+	cachePath := filepath.Join(dir, "cache.json")
+	added := filepath.Join(dir, "later", "node_modules")
+	if err := os.MkdirAll(added, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := cache.NewCache(cachePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := NewWatcher(c, 100*time.Millisecond, 100*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- w.Run(ctx) }()
+
+	// a scan in another process adds the folder after the watch started
+	scan, err := cache.NewCache(cachePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, _ := os.Stat(added)
+	scan.Set(added, &models.CacheEntry{Path: added, ModTime: info.ModTime(), LastScan: time.Now()})
+	if err := scan.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	waitFor(t, func() bool {
+		_, ok := c.Get(added)
+		return ok
+	})
+
+	// once followed, its removal is noticed
+	if err := os.RemoveAll(added); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool {
+		_, ok := c.Get(added)
+		return !ok
+	})
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Run() error = %v", err)
+	}
+}
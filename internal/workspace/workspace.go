@@ -0,0 +1,303 @@
+// Package workspace detects monorepo workspaces (npm, yarn, pnpm, bun and
+// Cargo) so their dependency folders are grouped and cleaned together:
+// members share the root install, and deleting only some of them leaves
+// it broken.
+package workspace
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/d4rthvadr/node-cleaner/internal/project"
+	"github.com/d4rthvadr/node-cleaner/pkg/models"
+	"github.com/d4rthvadr/node-cleaner/pkg/utils"
+	"github.com/pelletier/go-toml/v2"
+	"go.yaml.in/yaml/v3"
+)
+
+// Load reads the workspace defined in dir for the ecosystem of
+// folderType, or returns nil when dir doesn't define one
+func Load(dir, folderType string) *models.Workspace {
+	switch folderType {
+	case "Node.js":
+		return loadNode(dir)
+	case "Rust":
+		return loadCargo(dir)
+	}
+	return nil
+}
+
+// loadNode reads pnpm-workspace.yaml, or the workspaces field of
+// package.json used by npm, yarn and bun
+func loadNode(dir string) *models.Workspace {
+	var include, exclude []string
+	kind := ""
+
+	if data, err := os.ReadFile(filepath.Join(dir, "pnpm-workspace.yaml")); err == nil {
+		var m struct {
+			Packages []string `yaml:"packages"`
+		}
+		if yaml.Unmarshal(data, &m) != nil {
+			return nil
+		}
+		include, exclude = splitNegated(m.Packages)
+		kind = "pnpm"
+	} else if data, err := os.ReadFile(filepath.Join(dir, "package.json")); err == nil {
+		var m struct {
+			Workspaces json.RawMessage `json:"workspaces"`
+		}
+		if json.Unmarshal(data, &m) != nil || len(m.Workspaces) == 0 {
+			return nil
+		}
+		// either a list of globs or yarn's {"packages": [...]} form
+		var patterns []string
+		if json.Unmarshal(m.Workspaces, &patterns) != nil {
+			var obj struct {
+				Packages []string `json:"packages"`
+			}
+			if json.Unmarshal(m.Workspaces, &obj) != nil {
+				return nil
+			}
+			patterns = obj.Packages
+		}
+		include, exclude = splitNegated(patterns)
+	} else {
+		return nil
+	}
+
+	root := project.Load(dir, "Node.js")
+	if kind == "" && root != nil {
+		kind = root.PackageManager
+	}
+	return newWorkspace(dir, kind, root, include, exclude)
+}
+
+// loadCargo reads the [workspace] table of Cargo.toml
+func loadCargo(dir string) *models.Workspace {
+	data, err := os.ReadFile(filepath.Join(dir, "Cargo.toml"))
+	if err != nil {
+		return nil
+	}
+
+	var m struct {
+		Workspace *struct {
+			Members []string `toml:"members"`
+			Exclude []string `toml:"exclude"`
+		} `toml:"workspace"`
+	}
+	if toml.Unmarshal(data, &m) != nil || m.Workspace == nil {
+		return nil
+	}
+	return newWorkspace(dir, "cargo", project.Load(dir, "Rust"), m.Workspace.Members, m.Workspace.Exclude)
+}
+
+func newWorkspace(dir, kind string, root *models.Project, include, exclude []string) *models.Workspace {
+	ws := &models.Workspace{
+		Root:     dir,
+		Kind:     kind,
+		Name:     filepath.Base(dir),
+		Members:  cleanPatterns(include),
+		Excludes: cleanPatterns(exclude),
+	}
+	if root != nil {
+		ws.Name = root.Name
+	}
+	return ws
+}
+
+// splitNegated separates "!pattern" exclusions from the other patterns
+func splitNegated(patterns []string) (include, exclude []string) {
+	for _, p := range patterns {
+		if rest, ok := strings.CutPrefix(p, "!"); ok {
+			exclude = append(exclude, rest)
+		} else {
+			include = append(include, p)
+		}
+	}
+	return include, exclude
+}
+
+func cleanPatterns(patterns []string) []string {
+	var cleaned []string
+	for _, p := range patterns {
+		p = strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(p), "./"), "/")
+		if p != "" {
+			cleaned = append(cleaned, p)
+		}
+	}
+	return cleaned
+}
+
+// Contains reports whether dir is the workspace root or one of its members
+func Contains(ws *models.Workspace, dir string) bool {
+	rel, err := filepath.Rel(ws.Root, dir)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, "../") {
+		return false
+	}
+	if rel == "." {
+		return true
+	}
+
+	// patterns are relative to the workspace root
+	match := func(pattern string) bool {
+		return utils.MatchGlob("/"+pattern, "/"+filepath.ToSlash(rel))
+	}
+	for _, p := range ws.Excludes {
+		if match(p) {
+			return false
+		}
+	}
+	for _, p := range ws.Members {
+		if match(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// Finder looks up the workspace of each folder, reading every directory
+// once. Safe for concurrent use.
+type Finder struct {
+	mu   sync.Mutex
+	dirs map[string]*models.Workspace // by directory and folder type
+}
+
+func NewFinder() *Finder {
+	return &Finder{dirs: make(map[string]*models.Workspace)}
+}
+
+// Find returns the nearest workspace above folder that counts the
+// folder's directory as a member, or nil when it isn't in one
+func (f *Finder) Find(folder models.DependencyFolder) *models.Workspace {
+	dir := filepath.Dir(folder.Path)
+	for ws := dir; ; ws = filepath.Dir(ws) {
+		if w := f.load(ws, folder.Type); w != nil && Contains(w, dir) {
+			return w
+		}
+		if parent := filepath.Dir(ws); parent == ws {
+			return nil
+		}
+	}
+}
+
+func (f *Finder) load(dir, folderType string) *models.Workspace {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	key := dir + "\x00" + folderType
+	if w, ok := f.dirs[key]; ok {
+		return w
+	}
+	w := Load(dir, folderType)
+	f.dirs[key] = w
+	return w
+}
+
+// Key identifies a workspace among the folders of a scan. A directory can
+// define a JS and a Cargo workspace at once; they are separate units.
+func Key(w *models.Workspace) string {
+	return w.Root + "\x00" + w.Kind
+}
+
+// Expand completes a selection so every workspace it touches is cleaned
+// as a unit: the other folders of those workspaces in all are added, and
+// workspaces with a protected folder are dropped entirely. It returns the
+// completed selection and a note for each workspace it changed.
+func Expand(selected, all []models.DependencyFolder) ([]models.DependencyFolder, []string) {
+	members := make(map[string][]models.DependencyFolder)
+	for _, f := range all {
+		if f.Workspace != nil {
+			key := Key(f.Workspace)
+			members[key] = append(members[key], f)
+		}
+	}
+
+	chosen := make(map[string]bool, len(selected))
+	for _, f := range selected {
+		chosen[f.Path] = true
+	}
+
+	var expanded []models.DependencyFolder
+	var notes []string
+	done := make(map[string]bool)
+
+	for _, f := range selected {
+		if f.Workspace == nil {
+			expanded = append(expanded, f)
+			continue
+		}
+		key := Key(f.Workspace)
+		if done[key] {
+			continue
+		}
+		done[key] = true
+
+		added := 0
+		protected := ""
+		for _, m := range members[key] {
+			if m.Protected != "" && protected == "" {
+				protected = m.Path + ": " + m.Protected
+			}
+			if !chosen[m.Path] {
+				added++
+			}
+		}
+
+		switch {
+		case protected != "":
+			notes = append(notes, fmt.Sprintf("Skipping workspace %s, %s", f.Workspace.Name, protected))
+		case added > 0:
+			notes = append(notes, fmt.Sprintf("Adding %d more folders to clean workspace %s as a unit", added, f.Workspace.Name))
+			fallthrough
+		default:
+			expanded = append(expanded, members[key]...)
+		}
+	}
+	return expanded, notes
+}
+
+// Complete keeps only the workspaces that selected covers entirely: a
+// workspace with folders in all that are missing from selected, or that
+// are protected, is dropped rather than cleaned partially. It returns the
+// remaining selection and a note for each workspace it dropped.
+func Complete(selected, all []models.DependencyFolder) ([]models.DependencyFolder, []string) {
+	chosen := make(map[string]bool, len(selected))
+	for _, f := range selected {
+		chosen[f.Path] = true
+	}
+
+	total := make(map[string]int)
+	missing := make(map[string]int)
+	for _, f := range all {
+		if f.Workspace == nil {
+			continue
+		}
+		key := Key(f.Workspace)
+		total[key]++
+		if !chosen[f.Path] || f.Protected != "" {
+			missing[key]++
+		}
+	}
+
+	var kept []models.DependencyFolder
+	var notes []string
+	noted := make(map[string]bool)
+
+	for _, f := range selected {
+		if f.Workspace == nil || missing[Key(f.Workspace)] == 0 {
+			kept = append(kept, f)
+			continue
+		}
+		key := Key(f.Workspace)
+		if !noted[key] {
+			noted[key] = true
+			notes = append(notes, fmt.Sprintf("Skipping workspace %s, only %d of its %d folders qualify",
+				f.Workspace.Name, total[key]-missing[key], total[key]))
+		}
+	}
+	return kept, notes
+}
@@ -0,0 +1,190 @@
+package workspace
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/d4rthvadr/node-cleaner/pkg/models"
+)
+
+func TestFind(t *testing.T) {
+	tests := []struct {
+		name   string
+		files  map[string]string
+		folder string
+		typ    string
+		kind   string // "" when the folder is in no workspace
+	}{
+		{
+			name: "pnpm",
+			files: map[string]string{
+				"package.json":        `{"name": "mono"}`,
+				"pnpm-workspace.yaml": "packages:\n  - 'packages/*'\n  - '!packages/legacy'\n",
+			},
+			folder: "packages/ui/node_modules", typ: "Node.js", kind: "pnpm",
+		},
+		{
+			name: "pnpm exclude",
+			files: map[string]string{
+				"package.json":        `{"name": "mono"}`,
+				"pnpm-workspace.yaml": "packages:\n  - 'packages/*'\n  - '!packages/legacy'\n",
+			},
+			folder: "packages/legacy/node_modules", typ: "Node.js",
+		},
+		{
+			name: "npm workspaces array",
+			files: map[string]string{
+				"package.json":      `{"name": "mono", "workspaces": ["apps/*", "./libs/**"]}`,
+				"package-lock.json": "{}",
+			},
+			folder: "libs/core/utils/node_modules", typ: "Node.js", kind: "npm",
+		},
+		{
+			name: "yarn workspaces object",
+			files: map[string]string{
+				"package.json": `{"name": "mono", "workspaces": {"packages": ["apps/*"]}}`,
+				"yarn.lock":    "",
+			},
+			folder: "apps/web/node_modules", typ: "Node.js", kind: "yarn",
+		},
+		{
+			name: "Workspace root",
+			files: map[string]string{
+				"package.json": `{"name": "mono", "workspaces": ["apps/*"]}`,
+			},
+			folder: "node_modules", typ: "Node.js", kind: "npm",
+		},
+		{
+			name: "Outside the member globs",
+			files: map[string]string{
+				"package.json": `{"name": "mono", "workspaces": ["apps/*"]}`,
+			},
+			folder: "tools/gen/node_modules", typ: "Node.js",
+		},
+		{
+			name: "Cargo",
+			files: map[string]string{
+				"Cargo.toml": "[workspace]\nmembers = [\"crates/*\"]\nexclude = [\"crates/scratch\"]\n",
+			},
+			folder: "crates/cli/target", typ: "Rust", kind: "cargo",
+		},
+		{
+			name: "Cargo package without a workspace",
+			files: map[string]string{
+				"Cargo.toml": "[package]\nname = \"cli\"\n",
+			},
+			folder: "target", typ: "Rust",
+		},
+		{
+			name: "Other ecosystem",
+			files: map[string]string{
+				"package.json": `{"name": "mono", "workspaces": ["apps/*"]}`,
+			},
+			folder: "apps/api/.venv", typ: "Python",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			for name, content := range tt.files {
+				if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got := NewFinder().Find(models.DependencyFolder{Path: filepath.Join(root, tt.folder), Type: tt.typ})
+			switch {
+			case tt.kind == "" && got != nil:
+				t.Errorf("Find() = %+v; want nil", *got)
+			case tt.kind != "" && got == nil:
+				t.Errorf("Find() = nil; want a %s workspace", tt.kind)
+			case got != nil && (got.Root != root || got.Kind != tt.kind):
+				t.Errorf("Find() = %s workspace at %s; want %s at %s", got.Kind, got.Root, tt.kind, root)
+			}
+		})
+	}
+}
+
+func TestExpandAndComplete(t *testing.T) {
+	mono := &models.Workspace{Root: "/r/mono", Name: "mono"}
+	locked := &models.Workspace{Root: "/r/locked", Name: "locked"}
+
+	all := []models.DependencyFolder{
+		{Path: "/r/mono/node_modules", Workspace: mono},
+		{Path: "/r/mono/apps/web/node_modules", Workspace: mono},
+		{Path: "/r/locked/node_modules", Workspace: locked},
+		{Path: "/r/locked/apps/api/node_modules", Workspace: locked, Protected: "keep"},
+		{Path: "/r/solo/node_modules"},
+	}
+	paths := func(folders []models.DependencyFolder) []string {
+		var p []string
+		for _, f := range folders {
+			p = append(p, f.Path)
+		}
+		slices.Sort(p)
+		return p
+	}
+
+	expanded, notes := Expand([]models.DependencyFolder{all[1], all[2], all[4]}, all)
+	want := []string{"/r/mono/apps/web/node_modules", "/r/mono/node_modules", "/r/solo/node_modules"}
+	if got := paths(expanded); !slices.Equal(got, want) {
+		t.Errorf("Expand() = %v; want %v", got, want)
+	}
+	if len(notes) != 2 {
+		t.Errorf("Expand() notes = %q; want one for each workspace", notes)
+	}
+
+	kept, notes := Complete([]models.DependencyFolder{all[0], all[2], all[4]}, all)
+	want = []string{"/r/solo/node_modules"}
+	if got := paths(kept); !slices.Equal(got, want) {
+		t.Errorf("Complete() = %v; want %v", got, want)
+	}
+	if len(notes) != 2 {
+		t.Errorf("Complete() notes = %q; want one for each workspace", notes)
+	}
+
+	kept, notes = Complete(all[:2], all)
+	if len(kept) != 2 || len(notes) != 0 {
+		t.Errorf("Complete() of a whole workspace = %d folders, notes %q; want 2, none", len(kept), notes)
+	}
+}
+
+func TestJSAndCargoWorkspacesAtOneRoot(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"package.json":        `{"name": "mono"}`,
+		"pnpm-workspace.yaml": "packages:\n  - 'packages/*'\n",
+		"Cargo.toml":          "[workspace]\nmembers = [\"crates/*\"]\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	finder := NewFinder()
+	var all []models.DependencyFolder
+	for _, f := range []models.DependencyFolder{
+		{Path: filepath.Join(root, "node_modules"), Type: "Node.js"},
+		{Path: filepath.Join(root, "packages", "ui", "node_modules"), Type: "Node.js"},
+		{Path: filepath.Join(root, "target"), Type: "Rust"},
+	} {
+		if f.Workspace = finder.Find(f); f.Workspace == nil {
+			t.Fatalf("Find(%s) = nil; want a workspace", f.Path)
+		}
+		all = append(all, f)
+	}
+
+	expanded, _ := Expand(all[2:], all)
+	if len(expanded) != 1 || expanded[0].Path != all[2].Path {
+		t.Errorf("Expand() of the Cargo target = %d folders; want only the target", len(expanded))
+	}
+
+	kept, notes := Complete(all[:2], all)
+	if len(kept) != 2 || len(notes) != 0 {
+		t.Errorf("Complete() of the JS workspace = %d folders, notes %q; want 2, none", len(kept), notes)
+	}
+}
@@ -10,6 +10,57 @@ type DependencyFolder struct {
 	AccessTime   time.Time `json:"access_time"`
 	Type         string    `json:"type"`
 	Selected     bool      `json:"selected"`
+	// Tracked is set when git tracks files inside the folder (e.g. a committed vendor/)
+	Tracked bool `json:"tracked"`
+	// Protected is why a .depocleanerignore or .depocleaner.yaml marker
+	// protects the folder; empty when it is not protected
+	Protected string `json:"protected,omitempty"`
+	// Score rates how likely the project is abandoned, 0 to 100;
+	// ScoreReasons explain how it was reached
+	Score        int      `json:"score"`
+	ScoreReasons []string `json:"score_reasons,omitempty"`
+	// Git describes the repository the folder's project lives in, nil
+	// outside a repository
+	Git *GitInfo `json:"git,omitempty"`
+	// Project is the project the folder belongs to, nil when no manifest
+	// was found above it
+	Project *Project `json:"project,omitempty"`
+	// Workspace is the monorepo workspace the folder is a member of; its
+	// folders are cleaned together
+	Workspace *Workspace `json:"workspace,omitempty"`
+}
+
+// Workspace is a monorepo whose member packages share one install
+type Workspace struct {
+	Root     string   `json:"root"`
+	Kind     string   `json:"kind"` // npm, yarn, pnpm, bun or cargo
+	Name     string   `json:"name"`
+	Members  []string `json:"members"` // globs relative to Root
+	Excludes []string `json:"excludes,omitempty"`
+}
+
+// Project is the nearest directory above a dependency folder with a
+// manifest, and what its manifest and lockfile say
+type Project struct {
+	Root           string `json:"root"`
+	Manifest       string `json:"manifest"`  // e.g. package.json, Cargo.toml
+	Ecosystem      string `json:"ecosystem"` // node, python, rust, php or go
+	Name           string `json:"name"`
+	Version        string `json:"version,omitempty"`
+	PackageManager string `json:"package_manager"` // npm, yarn, pnpm, bun, poetry, uv, cargo, composer, ...
+	Lockfile       string `json:"lockfile,omitempty"`
+	LockfileHash   string `json:"lockfile_hash,omitempty"` // hex sha256
+}
+
+// GitInfo is repository metadata read directly from .git
+type GitInfo struct {
+	Root       string    `json:"root"`
+	Branch     string    `json:"branch,omitempty"` // empty when HEAD is detached
+	LastCommit time.Time `json:"last_commit,omitempty"`
+	// Dirty is set when tracked files differ from the index
+	Dirty     bool   `json:"dirty"`
+	Unpushed  bool   `json:"unpushed"`
+	RemoteURL string `json:"remote_url,omitempty"`
 }
 
 type FailedOp struct {
@@ -23,10 +74,39 @@ type Config struct {
 
 	IgnorePaths    []string `mapstructure:"ignore_paths" json:"ignore_paths"`
 	CachePath      string   `mapstructure:"cache_path" json:"cache_path"`
+	CacheBackend   string   `mapstructure:"cache_backend" json:"cache_backend"`
 	LogPath        string   `mapstructure:"log_path" json:"log_path"`
+	AuditPath      string   `mapstructure:"audit_path" json:"audit_path"`
+	LogLevel       string   `mapstructure:"log_level" json:"log_level"`
+	LogFormat      string   `mapstructure:"log_format" json:"log_format"`
 	FollowSymlinks bool     `mapstructure:"follow_symlinks" json:"follow_symlinks"`
 	MaxDepth       int      `mapstructure:"max_depth" json:"max_depth"`
 	Workers        int      `mapstructure:"workers" json:"workers"`
+	UseTrash       bool     `mapstructure:"use_trash" json:"use_trash"`
+	QuarantinePath string   `mapstructure:"quarantine_path" json:"quarantine_path"`
+	TombstonePath  string   `mapstructure:"tombstone_path" json:"tombstone_path"`
+	RestorePath    string   `mapstructure:"restore_path" json:"restore_path"`
+	ProtectedPaths []string `mapstructure:"protected_paths" json:"protected_paths"`
+	// Policies are ordered cleanup rules, the first matching rule wins
+	Policies []PolicyRule `mapstructure:"policies" json:"policies"`
+}
+
+// PolicyRule applies an action to every folder its match part selects
+type PolicyRule struct {
+	Name   string      `mapstructure:"name" json:"name"`
+	Match  PolicyMatch `mapstructure:"match" json:"match"`
+	Action string      `mapstructure:"action" json:"action"` // protect, suggest, auto-clean or trash
+}
+
+// PolicyMatch lists the conditions of a rule; empty fields match everything
+type PolicyMatch struct {
+	Path      string `mapstructure:"path" json:"path,omitempty"`           // glob, ** crosses directories
+	Ecosystem string `mapstructure:"ecosystem" json:"ecosystem,omitempty"` // e.g. node or "node,rust"
+	OlderThan string `mapstructure:"older_than" json:"older_than,omitempty"`
+	MinSize   string `mapstructure:"min_size" json:"min_size,omitempty"`
+	Tracked   *bool  `mapstructure:"tracked" json:"tracked,omitempty"`
+	// InSync matches folders installed after their project's lockfile last changed
+	InSync *bool `mapstructure:"in_sync" json:"in_sync,omitempty"`
 }
 
 // CacheEntry represents a cached folder information
@@ -64,4 +144,41 @@ type CleanResult struct {
 	SpaceReclaimed int64         `json:"space_reclaimed"`
 	Duration       time.Duration `json:"duration"`
 	DryRun         bool          `json:"dry_run"`
+	// SpaceMoved is the size of folders moved to the trash or quarantine,
+	// or left to a background reaper; their bytes are still on disk
+	SpaceMoved int64 `json:"space_moved,omitempty"`
+	// TrashedTo maps each folder moved to the trash to its new location
+	TrashedTo map[string]string `json:"trashed_to,omitempty"`
+	// QuarantineIDs maps each quarantined folder to its quarantine id
+	QuarantineIDs map[string]string `json:"quarantine_ids,omitempty"`
+	// FolderDurations records how long each folder took to process
+	FolderDurations map[string]time.Duration `json:"folder_durations,omitempty"`
+	// Warnings are non-fatal issues, e.g. folders deleted while in use
+	Warnings []string `json:"warnings,omitempty"`
+	// ArchivedTo maps each archived folder to its tar.gz
+	ArchivedTo map[string]string `json:"archived_to,omitempty"`
+	// ArchivedSize is the total compressed size of the archives written
+	ArchivedSize int64 `json:"archived_size,omitempty"`
+	// Tombstones lists renamed folders still being deleted in the background
+	Tombstones []string `json:"tombstones,omitempty"`
+}
+
+// CleanEventKind identifies the stage a folder reached while being cleaned
+type CleanEventKind int
+
+const (
+	CleanStarted CleanEventKind = iota
+	CleanProgress
+	CleanFinished
+	CleanFailed
+)
+
+// CleanEvent reports per-folder progress during a clean operation
+type CleanEvent struct {
+	Kind         CleanEventKind
+	Path         string
+	Size         int64         // folder size as measured by the scan
+	BytesRemoved int64         // bytes removed so far
+	Duration     time.Duration // set on finished and failed events
+	Err          error         // set on failed events
 }
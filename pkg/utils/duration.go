@@ -0,0 +1,36 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseAge parses human friendly ages such as "7d", "2w" or "36h".
+// Day and week suffixes are accepted on top of time.ParseDuration units.
+func ParseAge(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, fmt.Errorf("empty duration")
+	}
+
+	units := map[byte]time.Duration{
+		'd': 24 * time.Hour,
+		'w': 7 * 24 * time.Hour,
+	}
+
+	if unit, ok := units[value[len(value)-1]]; ok {
+		n, err := strconv.ParseFloat(value[:len(value)-1], 64)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid duration %q", value)
+		}
+		return time.Duration(n * float64(unit)), nil
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+	return d, nil
+}
@@ -0,0 +1,69 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseAge(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		value    string
+		expected time.Duration
+		wantErr  bool
+	}{
+		{
+			name:     "Parse days",
+			value:    "7d",
+			expected: 7 * 24 * time.Hour,
+		},
+		{
+			name:     "Parse weeks",
+			value:    "2w",
+			expected: 14 * 24 * time.Hour,
+		},
+		{
+			name:     "Parse fractional days",
+			value:    "1.5d",
+			expected: 36 * time.Hour,
+		},
+		{
+			name:     "Parse Go duration",
+			value:    "90m",
+			expected: 90 * time.Minute,
+		},
+		{
+			name:    "Reject empty value",
+			value:   "",
+			wantErr: true,
+		},
+		{
+			name:    "Reject missing number",
+			value:   "d",
+			wantErr: true,
+		},
+		{
+			name:    "Reject negative age",
+			value:   "-3d",
+			wantErr: true,
+		},
+		{
+			name:    "Reject unknown unit",
+			value:   "3y",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ParseAge(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseAge(%q) error = %v; wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if result != tt.expected {
+				t.Errorf("ParseAge(%q) = %v; want %v", tt.value, result, tt.expected)
+			}
+		})
+	}
+}
@@ -1,5 +1,12 @@
 package utils
 
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
 var targetDirectories = []string{
 	"node_modules",       // common Node.js dependencies folder
 	"node_modules_cache", // alternative Node.js cache folder
@@ -40,3 +47,68 @@ func IsTargetDirectory(name string) bool {
 	return false
 
 }
+
+// TombstoneMarker is part of the name a folder is renamed to right before
+// it is deleted, so a half-deleted folder never sits at its original path
+const TombstoneMarker = ".depocleaner-tombstone-"
+
+// IsTombstone reports whether name is a folder renamed for deletion
+func IsTombstone(name string) bool {
+	return strings.Contains(name, TombstoneMarker)
+}
+
+// ecosystemAliases maps the short names accepted on the command line
+// to the folder types reported by DetectType
+var ecosystemAliases = map[string]string{
+	"node":   "Node.js",
+	"nodejs": "Node.js",
+	"python": "Python",
+	"py":     "Python",
+	"rust":   "Rust",
+	"go":     "Go/PHP",
+	"php":    "Go/PHP",
+}
+
+// IsKnownEcosystem reports whether name is accepted by MatchesEcosystem
+func IsKnownEcosystem(name string) bool {
+	_, ok := ecosystemAliases[strings.ToLower(name)]
+	return ok
+}
+
+// MatchesEcosystem reports whether a folder type (as returned by DetectType)
+// belongs to the ecosystem name, e.g. "node" or "rust"
+func MatchesEcosystem(folderType, name string) bool {
+	return ecosystemAliases[strings.ToLower(name)] == folderType
+}
+
+// Lockfiles are the files that pin a project's dependencies
+var Lockfiles = []string{
+	"package-lock.json",
+	"npm-shrinkwrap.json",
+	"yarn.lock",
+	"pnpm-lock.yaml",
+	"bun.lockb",
+	"bun.lock",
+	"poetry.lock",
+	"uv.lock",
+	"Pipfile.lock",
+	"requirements.txt",
+	"Cargo.lock",
+	"composer.lock",
+	"go.sum",
+}
+
+// LatestLockfile returns the most recently modified lockfile in dir, or
+// ok == false when dir has none
+func LatestLockfile(dir string) (name string, modTime time.Time, ok bool) {
+	for _, candidate := range Lockfiles {
+		info, err := os.Stat(filepath.Join(dir, candidate))
+		if err != nil || info.IsDir() {
+			continue
+		}
+		if !ok || info.ModTime().After(modTime) {
+			name, modTime, ok = candidate, info.ModTime(), true
+		}
+	}
+	return name, modTime, ok
+}
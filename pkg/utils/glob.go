@@ -0,0 +1,62 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// MatchGlob reports whether path matches pattern. Besides the usual
+// * and ? (which never cross a "/"), ** matches any number of directories.
+// Patterns that are not absolute may match anywhere in the path, so
+// "clients/**" matches /home/me/clients/acme/node_modules. A leading ~
+// is expanded to the home directory.
+func MatchGlob(pattern, path string) bool {
+	re, err := globRegexp(pattern)
+	if err != nil {
+		return false
+	}
+	return re.MatchString(filepath.ToSlash(filepath.Clean(path)))
+}
+
+func globRegexp(pattern string) (*regexp.Regexp, error) {
+	if strings.HasPrefix(pattern, "~") {
+		if home, err := os.UserHomeDir(); err == nil {
+			pattern = home + pattern[1:]
+		}
+	}
+	pattern = filepath.ToSlash(pattern)
+
+	var b strings.Builder
+	b.WriteString("^")
+	if !strings.HasPrefix(pattern, "/") {
+		b.WriteString("(?:.*/)?")
+	}
+
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				i++
+				// "**/" may also match no directory at all
+				if i+1 < len(pattern) && pattern[i+1] == '/' {
+					i++
+					b.WriteString("(?:.*/)?")
+				} else {
+					b.WriteString(".*")
+				}
+				continue
+			}
+			b.WriteString("[^/]*")
+		case '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	// a pattern naming a directory also matches everything below it
+	b.WriteString("(?:/.*)?$")
+	return regexp.Compile(b.String())
+}
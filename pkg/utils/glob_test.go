@@ -0,0 +1,65 @@
+package utils
+
+import "testing"
+
+func TestMatchGlob(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		pattern  string
+		path     string
+		expected bool
+	}{
+		{
+			name:     "Relative pattern matches anywhere",
+			pattern:  "clients/**",
+			path:     "/home/dev/clients/acme/node_modules",
+			expected: true,
+		},
+		{
+			name:     "Star does not cross directories",
+			pattern:  "/home/*/node_modules",
+			path:     "/home/dev/app/node_modules",
+			expected: false,
+		},
+		{
+			name:     "Double star crosses directories",
+			pattern:  "/home/**/node_modules",
+			path:     "/home/dev/app/node_modules",
+			expected: true,
+		},
+		{
+			name:     "Double star matches zero directories",
+			pattern:  "/home/**/node_modules",
+			path:     "/home/node_modules",
+			expected: true,
+		},
+		{
+			name:     "Directory pattern matches children",
+			pattern:  "work",
+			path:     "/home/dev/work/api/vendor",
+			expected: true,
+		},
+		{
+			name:     "Partial component does not match",
+			pattern:  "work",
+			path:     "/home/dev/homework/vendor",
+			expected: false,
+		},
+		{
+			name:     "Question mark matches one character",
+			pattern:  "app?/target",
+			path:     "/src/app2/target",
+			expected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := MatchGlob(tt.pattern, tt.path)
+			if result != tt.expected {
+				t.Errorf("MatchGlob(%q, %q) = %v; want %v", tt.pattern, tt.path, result, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// FileLock is an advisory flock(2) lock held on a sidecar ".lock" file.
+// Locking a separate file (instead of the data file itself) keeps the
+// lock stable while the data file is replaced via rename.
+type FileLock struct {
+	f *os.File
+}
+
+// AcquireLock blocks until a shared (readers) or exclusive (writers)
+// lock is held on lockPath. The lock file is created if it does not exist.
+func AcquireLock(lockPath string, exclusive bool) (*FileLock, error) {
+	f, err := os.OpenFile(lockPath, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("opening lock file: %w", err)
+	}
+
+	how := syscall.LOCK_SH
+	if exclusive {
+		how = syscall.LOCK_EX
+	}
+
+	for {
+		err = syscall.Flock(int(f.Fd()), how)
+		if err != syscall.EINTR {
+			break
+		}
+	}
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("locking %s: %w", lockPath, err)
+	}
+
+	return &FileLock{f: f}, nil
+}
+
+// Release drops the lock and closes the underlying file
+func (l *FileLock) Release() error {
+	if l == nil || l.f == nil {
+		return nil
+	}
+	defer l.f.Close()
+	return syscall.Flock(int(l.f.Fd()), syscall.LOCK_UN)
+}
+
+// TryLock takes an exclusive lock on lockPath without waiting.
+// It returns a nil lock when another process already holds it.
+func TryLock(lockPath string) (*FileLock, error) {
+	f, err := os.OpenFile(lockPath, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("opening lock file: %w", err)
+	}
+
+	for {
+		err = syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+		if err != syscall.EINTR {
+			break
+		}
+	}
+	if err == syscall.EWOULDBLOCK {
+		f.Close()
+		return nil, nil
+	}
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("locking %s: %w", lockPath, err)
+	}
+
+	return &FileLock{f: f}, nil
+}
@@ -0,0 +1,29 @@
+package utils
+
+import (
+	"path/filepath"
+	"syscall"
+)
+
+// DeviceOf returns the device ID of the filesystem holding path,
+// or the max uint64 if path cannot be stat'ed
+func DeviceOf(path string) uint64 {
+	var st syscall.Stat_t
+	if err := syscall.Stat(path, &st); err != nil {
+		return ^uint64(0)
+	}
+	return uint64(st.Dev)
+}
+
+// MountTop walks up from abs to the highest directory still on device dev,
+// i.e. the mount point of the filesystem abs lives on
+func MountTop(abs string, dev uint64) string {
+	dir := abs
+	for {
+		parent := filepath.Dir(dir)
+		if parent == dir || DeviceOf(parent) != dev {
+			return dir
+		}
+		dir = parent
+	}
+}
@@ -0,0 +1,16 @@
+package utils
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// IsWithin reports whether path is dir itself or lies underneath it.
+// Both paths are compared in cleaned form; symlinks are not resolved.
+func IsWithin(path, dir string) bool {
+	rel, err := filepath.Rel(filepath.Clean(dir), filepath.Clean(path))
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
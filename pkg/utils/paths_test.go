@@ -0,0 +1,59 @@
+package utils
+
+import "testing"
+
+func TestIsWithin(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		path     string
+		dir      string
+		expected bool
+	}{
+		{
+			name:     "Path inside dir",
+			path:     "/home/dev/app/node_modules",
+			dir:      "/home/dev",
+			expected: true,
+		},
+		{
+			name:     "Path equal to dir",
+			path:     "/home/dev/",
+			dir:      "/home/dev",
+			expected: true,
+		},
+		{
+			name:     "Sibling with common prefix",
+			path:     "/home/developer/app",
+			dir:      "/home/dev",
+			expected: false,
+		},
+		{
+			name:     "Parent of dir",
+			path:     "/home",
+			dir:      "/home/dev",
+			expected: false,
+		},
+		{
+			name:     "Dot-dot segments are cleaned",
+			path:     "/home/dev/../other/app",
+			dir:      "/home/dev",
+			expected: false,
+		},
+		{
+			name:     "Everything is within root",
+			path:     "/srv/app",
+			dir:      "/",
+			expected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := IsWithin(tt.path, tt.dir)
+			if result != tt.expected {
+				t.Errorf("IsWithin(%q, %q) = %v; want %v", tt.path, tt.dir, result, tt.expected)
+			}
+		})
+	}
+}
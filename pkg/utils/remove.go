@@ -0,0 +1,187 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// progressStep is how many bytes are removed between progress reports
+const progressStep = 32 << 20 // 32MB
+
+// maxListedFailures caps how many paths RemoveError spells out
+const maxListedFailures = 5
+
+// RemoveFailure is one entry RemoveTree could not delete
+type RemoveFailure struct {
+	Path string
+	Err  error
+}
+
+// RemoveError lists every entry left behind by RemoveTree
+type RemoveError struct {
+	Failures []RemoveFailure
+}
+
+func (e *RemoveError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "could not remove %d entries: ", len(e.Failures))
+	for i, f := range e.Failures {
+		if i == maxListedFailures {
+			fmt.Fprintf(&b, ", and %d more", len(e.Failures)-i)
+			break
+		}
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		cause := f.Err
+		var pathErr *fs.PathError
+		if errors.As(cause, &pathErr) {
+			cause = pathErr.Err
+		}
+		fmt.Fprintf(&b, "%s (%v)", f.Path, cause)
+	}
+	return b.String()
+}
+
+// RemoveTree deletes path depth-first like os.RemoveAll, but reports the
+// number of bytes removed so far through onProgress and stops between
+// entries when ctx is cancelled.
+//
+// Read-only trees such as the Go module cache (directories are 0555) are
+// handled by adding owner permissions to a directory we own when removing
+// from it fails with EACCES, then retrying. The directory holding the tree
+// is only unlocked while path itself is removed, then restored. Entries
+// that still cannot be removed don't stop the walk; they are returned
+// together in a *RemoveError.
+func RemoveTree(ctx context.Context, path string, onProgress func(removed int64)) error {
+	var removed, reported int64
+	var failures []RemoveFailure
+
+	report := func() {
+		if onProgress != nil && removed != reported {
+			onProgress(removed)
+			reported = removed
+		}
+	}
+
+	// remove deletes one entry of dir, unlocking dir once if it is read-only
+	remove := func(dir, p string) bool {
+		err := os.Remove(p)
+		if errors.Is(err, fs.ErrPermission) && dir != "" && makeWritable(dir) {
+			err = os.Remove(p)
+		}
+		if errors.Is(err, syscall.ENOTEMPTY) && len(failures) > 0 {
+			return false // the entries left inside are already reported
+		}
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			failures = append(failures, RemoveFailure{Path: p, Err: err})
+			return false
+		}
+		return true
+	}
+
+	var walk func(dir string) error
+	walk = func(dir string) error {
+		entries, err := os.ReadDir(dir)
+		if errors.Is(err, fs.ErrPermission) && makeWritable(dir) {
+			entries, err = os.ReadDir(dir)
+		}
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			failures = append(failures, RemoveFailure{Path: dir, Err: err})
+			return nil
+		}
+
+		for _, entry := range entries {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			p := filepath.Join(dir, entry.Name())
+
+			// symlinks to directories are removed as links, never followed
+			if entry.IsDir() {
+				if err := walk(p); err != nil {
+					return err
+				}
+				remove(dir, p)
+				continue
+			}
+
+			var size int64
+			if info, err := entry.Info(); err == nil {
+				size = info.Size()
+			}
+			if !remove(dir, p) {
+				continue
+			}
+
+			removed += size
+			if removed-reported >= progressStep {
+				report()
+			}
+		}
+		return nil
+	}
+
+	err := walk(path)
+	if err == nil {
+		rmErr := WithWritableParent(path, func() error { return os.Remove(path) })
+		switch {
+		case rmErr == nil, errors.Is(rmErr, fs.ErrNotExist):
+		case errors.Is(rmErr, syscall.ENOTEMPTY) && len(failures) > 0:
+		default:
+			failures = append(failures, RemoveFailure{Path: path, Err: rmErr})
+		}
+	}
+	report()
+
+	if err != nil {
+		return err
+	}
+	if len(failures) > 0 {
+		return &RemoveError{Failures: failures}
+	}
+	return nil
+}
+
+// WithWritableParent runs fn, which renames or removes path. When that
+// fails with a permission error and the directory holding path is a
+// read-only directory we own (e.g. inside the Go module cache), fn is
+// retried with owner access to it, and its mode is restored afterwards.
+func WithWritableParent(path string, fn func() error) error {
+	err := fn()
+	if !errors.Is(err, fs.ErrPermission) {
+		return err
+	}
+
+	parent := filepath.Dir(path)
+	info, statErr := os.Lstat(parent)
+	if statErr != nil || !makeWritable(parent) {
+		return err
+	}
+	defer os.Chmod(parent, info.Mode().Perm())
+	return fn()
+}
+
+// makeWritable gives the owner full access to dir when it belongs to us,
+// reporting whether anything was changed
+func makeWritable(dir string) bool {
+	info, err := os.Lstat(dir)
+	if err != nil || !info.IsDir() {
+		return false
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok || int(stat.Uid) != os.Getuid() {
+		return false
+	}
+	if info.Mode().Perm()&0700 == 0700 {
+		return false // already accessible, the error has another cause
+	}
+	return os.Chmod(dir, info.Mode().Perm()|0700) == nil
+}
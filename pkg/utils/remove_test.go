@@ -0,0 +1,101 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRemoveTreeReportsBytes(t *testing.T) {
+	tree := filepath.Join(t.TempDir(), "node_modules")
+	if err := os.MkdirAll(filepath.Join(tree, "pkg"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tree, "pkg", "index.js"), make([]byte, 100), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var last int64
+	err := RemoveTree(context.Background(), tree, func(removed int64) {
+		last = removed
+	})
+	if err != nil {
+		t.Fatalf("RemoveTree() error = %v", err)
+	}
+	if last != 100 {
+		t.Errorf("reported %d bytes removed; want 100", last)
+	}
+}
+
+func TestRemoveTreeHandlesReadOnlyDirectories(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("permission bits do not restrict root")
+	}
+	root := t.TempDir()
+	tree := filepath.Join(root, "mod")
+	deep := filepath.Join(tree, "golang.org", "x", "text@v0.14.0")
+	if err := os.MkdirAll(deep, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(deep, "go.mod"), []byte("module x"), 0444); err != nil {
+		t.Fatal(err)
+	}
+
+	// lock the tree down like the Go module cache does
+	for _, dir := range []string{deep, filepath.Dir(deep), filepath.Dir(filepath.Dir(deep)), tree} {
+		if err := os.Chmod(dir, 0555); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Cleanup(func() {
+		filepath.WalkDir(root, func(p string, d os.DirEntry, err error) error {
+			if err == nil && d.IsDir() {
+				os.Chmod(p, 0755)
+			}
+			return nil
+		})
+	})
+
+	if err := RemoveTree(context.Background(), tree, nil); err != nil {
+		t.Fatalf("RemoveTree() error = %v", err)
+	}
+	if _, err := os.Stat(tree); !os.IsNotExist(err) {
+		t.Errorf("%s still exists", tree)
+	}
+}
+
+func TestMakeWritable(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "ro")
+	if err := os.Mkdir(dir, 0555); err != nil {
+		t.Fatal(err)
+	}
+
+	if !makeWritable(dir) {
+		t.Fatal("makeWritable() = false for a read-only directory we own")
+	}
+	info, _ := os.Stat(dir)
+	if info.Mode().Perm() != 0755 {
+		t.Errorf("mode = %v; want 0755", info.Mode().Perm())
+	}
+	if makeWritable(dir) {
+		t.Error("makeWritable() = true for a directory that was already writable")
+	}
+}
+
+func TestRemoveErrorListsEntries(t *testing.T) {
+	err := &RemoveError{}
+	for i := 0; i < 7; i++ {
+		err.Failures = append(err.Failures, RemoveFailure{
+			Path: fmt.Sprintf("/m/pkg%d", i),
+			Err:  &os.PathError{Op: "unlinkat", Path: fmt.Sprintf("/m/pkg%d", i), Err: os.ErrPermission},
+		})
+	}
+
+	want := "could not remove 7 entries: /m/pkg0 (permission denied), /m/pkg1 (permission denied), " +
+		"/m/pkg2 (permission denied), /m/pkg3 (permission denied), /m/pkg4 (permission denied), and 2 more"
+	if got := err.Error(); got != want {
+		t.Errorf("Error() = %q\nwant      %q", got, want)
+	}
+}